@@ -0,0 +1,100 @@
+package ingaugo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// Account is a bank account belonging to the logged in client
+type Account struct {
+	Number           string
+	BSB              string
+	ProductName      string
+	Nickname         string
+	Type             string
+	CurrentBalance   Amount
+	AvailableBalance Amount
+}
+
+// dashboardResponse is the assumed shape of the dashboard API response, see dashboardPath. ingtest serves the
+// same shape, so its tests don't confirm it
+type dashboardResponse struct {
+	Response struct {
+		Categories []struct {
+			Name     string
+			Accounts []dashboardAccount
+		}
+		ErrorMessage string
+	}
+}
+
+type dashboardAccount struct {
+	AccountNumber    string
+	BSB              string
+	ProductName      string
+	AccountName      string
+	AccountType      string
+	CurrentBalance   json.Number
+	AvailableBalance json.Number
+}
+
+// Accounts returns all accounts of the client the session belongs to.
+// The dashboard API it calls hasn't been confirmed against the live ING API yet
+func (bank *Bank) Accounts(ctx context.Context, session *Session) (accounts []Account, err error) {
+	err = session.do(ctx, func(token string) error {
+		accounts, err = bank.getAccounts(ctx, token)
+		return err
+	})
+	return
+}
+
+// Accounts returns all accounts of the client. See Bank.Accounts
+func (s *Session) Accounts(ctx context.Context) ([]Account, error) {
+	return s.bank.Accounts(ctx, s)
+}
+
+func (bank *Bank) getAccounts(ctx context.Context, authToken string) ([]Account, error) {
+	data := url.Values{}
+	data.Set("X-AuthToken", authToken)
+	body, err := bank.post(ctx, bank.dashboardURL(), data)
+	if err != nil {
+		return nil, err
+	}
+	dr := dashboardResponse{}
+	if err := json.Unmarshal(body, &dr); err != nil {
+		return nil, fmt.Errorf("invalid dashboard response: %w", err)
+	}
+	if dr.Response.ErrorMessage != "" {
+		return nil, fmt.Errorf("dashboard error '%s'", dr.Response.ErrorMessage)
+	}
+
+	accounts := make([]Account, 0)
+	for _, c := range dr.Response.Categories {
+		for _, a := range c.Accounts {
+			acct := Account{
+				Number:      a.AccountNumber,
+				BSB:         a.BSB,
+				ProductName: a.ProductName,
+				Nickname:    a.AccountName,
+				Type:        a.AccountType,
+			}
+			if acct.CurrentBalance, err = parseBalance(a.CurrentBalance); err != nil {
+				return nil, fmt.Errorf("account %s: %w", a.AccountNumber, err)
+			}
+			if acct.AvailableBalance, err = parseBalance(a.AvailableBalance); err != nil {
+				return nil, fmt.Errorf("account %s: %w", a.AccountNumber, err)
+			}
+			accounts = append(accounts, acct)
+		}
+	}
+	return accounts, nil
+}
+
+func parseBalance(n json.Number) (Amount, error) {
+	if n == "" {
+		return 0, nil
+	}
+	return ParseAmount(n.String())
+}
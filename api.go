@@ -0,0 +1,83 @@
+package ingaugo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// dashboardPath is the API the web app is assumed to load the account list from. It follows the naming of the
+// export API but hasn't been confirmed against a captured response from ING, and neither has dashboardResponse
+const dashboardPath = "/api/Dashboard/Service/DashboardService.svc/json/Dashboard/loaddashboard"
+
+func (bank *Bank) dashboardURL() string {
+	return bank.baseURL + dashboardPath
+}
+
+// post submits form data to an ING API endpoint and returns the response body. Attempts failing with
+// ErrBankUnavailable are retried according to the Bank's RetryPolicy
+func (bank *Bank) post(ctx context.Context, u string, data url.Values) ([]byte, error) {
+	policy := bank.retryPolicy
+	for attempt := 1; ; attempt++ {
+		body, retryAfter, err := bank.postOnce(ctx, u, data, attempt)
+		if err == nil || attempt >= policy.MaxAttempts || !errors.Is(err, ErrBankUnavailable) || ctx.Err() != nil {
+			return body, err
+		}
+		wait := policy.backoff(attempt, retryAfter)
+		bank.logger.Warn("API call failed, retrying", "url", u, "attempt", attempt, "wait", wait, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// postOnce makes a single attempt of post. It also returns the wait requested by a Retry-After header
+func (bank *Bank) postOnce(ctx context.Context, u string, data url.Values, attempt int) ([]byte, time.Duration, error) {
+	bank.logger.Info("Fetching page", "url", u, "attempt", attempt)
+	req, err := http.NewRequestWithContext(ctx, "POST", u, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", bank.userAgent)
+	resp, err := bank.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, 0, err
+		}
+		return nil, 0, &kindError{kind: ErrBankUnavailable, err: err}
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode != 200 {
+		bank.logger.Info("Response body", "body", string(body))
+		httpErr := &HTTPError{URL: u, StatusCode: resp.StatusCode, Body: body}
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+			httpErr.err = ErrTokenExpired
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			httpErr.err = ErrBankUnavailable
+		}
+		return nil, parseRetryAfter(resp.Header.Get("Retry-After")), httpErr
+	}
+
+	return body, 0, nil
+}
+
+// validateToken makes a lightweight API call to check the token is still accepted. It uses the unconfirmed
+// dashboard API, so only ErrTokenExpired means the token was rejected
+func (bank *Bank) validateToken(ctx context.Context, authToken string) error {
+	data := url.Values{}
+	data.Set("X-AuthToken", authToken)
+	_, err := bank.post(ctx, bank.dashboardURL(), data)
+	return err
+}
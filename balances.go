@@ -0,0 +1,42 @@
+package ingaugo
+
+import (
+	"context"
+	"time"
+)
+
+// Balance is the balance of an account at a point in time
+type Balance struct {
+	AccountNumber string
+	Current       Amount
+	Available     Amount
+	Time          time.Time
+}
+
+// Balances returns the current and available balance of every account of the client the session belongs to
+func (bank *Bank) Balances(ctx context.Context, session *Session) ([]Balance, error) {
+	accounts, err := bank.Accounts(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	return AccountBalances(accounts, time.Now()), nil
+}
+
+// AccountBalances returns the balances of accounts, as read at time at
+func AccountBalances(accounts []Account, at time.Time) []Balance {
+	balances := make([]Balance, 0, len(accounts))
+	for _, a := range accounts {
+		balances = append(balances, Balance{
+			AccountNumber: a.Number,
+			Current:       a.CurrentBalance,
+			Available:     a.AvailableBalance,
+			Time:          at,
+		})
+	}
+	return balances
+}
+
+// Balances returns the balance of every account of the client. See Bank.Balances
+func (s *Session) Balances(ctx context.Context) ([]Balance, error) {
+	return s.bank.Balances(ctx, s)
+}
@@ -2,23 +2,134 @@
 package ingaugo
 
 import (
+	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"time"
 
+	dp "github.com/chromedp/chromedp"
 	"golang.org/x/exp/slog"
 )
 
+const (
+	defaultBaseURL = "https://www.ing.com.au"
+
+	loginPath              = "/securebanking/"
+	tokenPath              = "/api/token/login/issue"
+	exportTransactionsPath = "/api/ExportTransactions/Service/ExportTransactionsService.svc/json/ExportTransactions/ExportTransactions"
+
+	// ING doesn't publish the token lifetime, so err on the short side. Expired tokens are also detected by the API response
+	defaultTokenLifetime = 5 * time.Minute
+
+	// remote debugging port assumed for browser URLs without a port
+	defaultDebuggingPort = "9222"
+
+	defaultKeypadTimeout = 10 * time.Second
+	defaultLoginBackoff  = 2 * time.Second
+
+	// Make Go HTTP client user-agent match headless-shell user-agent
+	defaultUserAgent = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/98.0.4758.102 Safari/537.36"
+)
+
 type Bank struct {
-	wsURL  string
-	logger *slog.Logger
+	wsURL      string
+	logger     *slog.Logger
+	baseURL    string
+	httpClient *http.Client
+	transport  http.RoundTripper
+	userAgent  string
+	timeout    time.Duration
+	allocOpts  []dp.ExecAllocatorOption
+
+	browserPath  string
+	headless     bool
+	userDataDir  string
+	proxy        *url.URL
+	windowWidth  int
+	windowHeight int
+	pool         *browserPool
+
+	tokenLifetime time.Duration
+	tokenStore    TokenStore
+
+	keypadRecognizer KeypadRecognizer
+	keypadTimeout    time.Duration
+	challengeHandler ChallengeHandler
+	diagnosticsDir   string
+
+	loginRetries int
+	loginBackoff time.Duration
+
+	retryPolicy RetryPolicy
 }
 
-// NewBank is used to initialize and return a Bank
-// if websocketURL is not empty, the package will connect to browser instances listing at that location
-// otherwise, the package will attempt to launch a local browser instance. It depends on 'google-chrome' executable being in $PATH
-func NewBank(logger *slog.Logger, websocketURL string) (*Bank, error) {
+// NewBank is used to initialize and return a Bank.
+// With no options, the package will attempt to launch a local browser instance. It depends on 'google-chrome' executable being in $PATH
+// and talks to the live ING endpoints. See the With* functions for available options.
+func NewBank(opts ...Option) (*Bank, error) {
+	bank := &Bank{
+		baseURL:   defaultBaseURL,
+		userAgent: defaultUserAgent,
+
+		tokenLifetime: defaultTokenLifetime,
+
+		headless: true,
+
+		keypadRecognizer: IconRecognizer{},
+		keypadTimeout:    defaultKeypadTimeout,
 
-	if logger == nil {
-		logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
+		loginBackoff: defaultLoginBackoff,
+
+		retryPolicy: DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		if err := opt(bank); err != nil {
+			return nil, err
+		}
+	}
+
+	if bank.logger == nil {
+		bank.logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
+	}
+
+	// take a copy so that the caller's client is never modified
+	client := &http.Client{}
+	if bank.httpClient != nil {
+		*client = *bank.httpClient
+	}
+	if bank.transport != nil {
+		client.Transport = bank.transport
+	} else if bank.proxy != nil {
+		// keep the TLS config and other settings of the caller's transport, only adding the proxy
+		base := client.Transport
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		transport, ok := base.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf("proxy can't be set on HTTP client transport %T, use WithTransport instead", base)
+		}
+		transport = transport.Clone()
+		transport.Proxy = http.ProxyURL(bank.proxy)
+		client.Transport = transport
+	}
+	if bank.timeout > 0 {
+		client.Timeout = bank.timeout
 	}
-	return &Bank{logger: logger, wsURL: websocketURL}, nil
+	bank.httpClient = client
+
+	return bank, nil
+}
+
+func (bank *Bank) loginURL() string {
+	return bank.baseURL + loginPath
+}
+
+func (bank *Bank) tokenURL() string {
+	return bank.baseURL + tokenPath
+}
+
+func (bank *Bank) exportTransactionsURL() string {
+	return bank.baseURL + exportTransactionsPath
 }
@@ -0,0 +1,113 @@
+package ingaugo
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+
+	dp "github.com/chromedp/chromedp"
+)
+
+// headlessShells are browser binaries that can only run headless, preferred when running headless as they start quickest
+var headlessShells = []string{
+	"headless-shell",
+	"headless_shell",
+	// chromedp/headless-shell docker image
+	"/headless-shell/headless-shell",
+}
+
+// browsers are full browser binaries, searched for in order after headlessShells
+var browsers = map[string][]string{
+	"darwin": {
+		"/Applications/Chromium.app/Contents/MacOS/Chromium",
+		"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+	},
+	"windows": {
+		"chrome",
+		"chrome.exe",
+		`C:\Program Files\Google\Chrome\Application\chrome.exe`,
+		`C:\Program Files (x86)\Google\Chrome\Application\chrome.exe`,
+	},
+	"linux": {
+		"chromium",
+		"chromium-browser",
+		"google-chrome",
+		"google-chrome-stable",
+		"/usr/bin/google-chrome",
+		"/usr/local/bin/chrome",
+		"/snap/bin/chromium",
+		"chrome",
+	},
+}
+
+// findBrowser returns the path of the first browser binary found, or an empty string if there is none
+func findBrowser(headless bool) string {
+	var candidates []string
+	if headless {
+		candidates = append(candidates, headlessShells...)
+	}
+	candidates = append(candidates, browsers[runtime.GOOS]...)
+	for _, candidate := range candidates {
+		if path, err := exec.LookPath(candidate); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// browserContext returns a chromedp context for a new browser tab. The browser is either the remote
+// instance at the websocket URL or a newly launched local instance. With a browser pool, the tab is opened
+// in a new incognito context of the pool's browser, which must have been acquired
+func (bank *Bank) browserContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if bank.pool != nil {
+		return bank.pool.tab(ctx)
+	}
+	ctx, allocCancel := bank.allocatorContext(ctx)
+	//ctx, cancel = dp.NewContext(ctx, chromedp.WithDebugf(log.Printf))
+	ctx, cancel := dp.NewContext(ctx)
+	return ctx, func() {
+		cancel()
+		allocCancel()
+	}
+}
+
+// allocatorContext returns a chromedp allocator context for the remote browser at the websocket URL,
+// or for launching a local browser
+func (bank *Bank) allocatorContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if bank.wsURL != "" {
+		return dp.NewRemoteAllocator(ctx, bank.wsURL)
+	}
+	return dp.NewExecAllocator(ctx, bank.execAllocatorOptions()...)
+}
+
+// execAllocatorOptions returns the chromedp options for launching a local browser
+func (bank *Bank) execAllocatorOptions() []dp.ExecAllocatorOption {
+	opts := append([]dp.ExecAllocatorOption{}, dp.DefaultExecAllocatorOptions[:]...)
+
+	browserPath := bank.browserPath
+	if browserPath == "" {
+		browserPath = findBrowser(bank.headless)
+		if browserPath == "" {
+			bank.logger.Warn("No Chrome or Chromium binary found, set one with WithBrowserPath")
+		} else {
+			bank.logger.Debug("Found browser", "path", browserPath)
+		}
+	}
+	if browserPath != "" {
+		opts = append(opts, dp.ExecPath(browserPath))
+	}
+	if !bank.headless {
+		opts = append(opts, dp.Flag("headless", false), dp.Flag("hide-scrollbars", false), dp.Flag("mute-audio", false))
+	}
+	if bank.userDataDir != "" {
+		opts = append(opts, dp.UserDataDir(bank.userDataDir))
+	}
+	if bank.proxy != nil {
+		opts = append(opts, dp.ProxyServer(bank.proxy.String()))
+	}
+	if bank.windowWidth > 0 && bank.windowHeight > 0 {
+		opts = append(opts, dp.WindowSize(bank.windowWidth, bank.windowHeight))
+	}
+
+	return append(opts, bank.allocOpts...)
+}
@@ -0,0 +1,98 @@
+package ingaugo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	dp "github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/kb"
+)
+
+// challengeCodeSelector matches the verification code input of a step-up authentication challenge.
+// One-time code inputs are marked with autocomplete="one-time-code" so that browsers can fill them from SMS
+const challengeCodeSelector = `input[autocomplete="one-time-code"]`
+
+// Challenge is a step-up authentication request presented after the access pin has been accepted,
+// e.g. for a code sent by SMS or shown in the ING app
+type Challenge struct {
+	ClientNumber string
+	// Message is the text shown with the challenge, e.g. where the code was sent
+	Message string
+}
+
+// ChallengeHandler supplies the verification code for a step-up authentication challenge
+type ChallengeHandler interface {
+	Code(ctx context.Context, challenge Challenge) (string, error)
+}
+
+// ChallengeHandlerFunc adapts a function to a ChallengeHandler
+type ChallengeHandlerFunc func(ctx context.Context, challenge Challenge) (string, error)
+
+func (f ChallengeHandlerFunc) Code(ctx context.Context, challenge Challenge) (string, error) {
+	return f(ctx, challenge)
+}
+
+// challengePollInterval is how often the page is checked for a challenge while waiting for the token
+const challengePollInterval = 250 * time.Millisecond
+
+// challengeScript returns the text shown with the first visible challenge code input that hasn't been answered,
+// or an empty string. An input counts as answered while it still holds the code typed into it by answerChallenge,
+// so a page that clears the input or shows a new one after a mistyped code is seen as a new challenge
+const challengeScript = `(() => {
+	for (const input of document.querySelectorAll('` + challengeCodeSelector + `')) {
+		if (input.offsetParent === null || input.value === input.dataset.ingaugoAnswered) {
+			continue;
+		}
+		const container = input.closest('form') || input.parentElement;
+		return (container && container.innerText) || ' ';
+	}
+	return '';
+})()`
+
+// watchChallenge sends the message of a step-up challenge on the returned channel once an unanswered challenge
+// code input is shown. It sends at most once, so call it again after answering to watch for the next challenge
+func (bank *Bank) watchChallenge(ctx context.Context) <-chan string {
+	challengeChan := make(chan string, 1)
+	go func() {
+		ticker := time.NewTicker(challengePollInterval)
+		defer ticker.Stop()
+		for {
+			var message string
+			// errors are expected while the page navigates, so keep polling until ctx is cancelled once the login is finished
+			if err := dp.Run(ctx, dp.Evaluate(challengeScript, &message)); err == nil && message != "" {
+				challengeChan <- strings.TrimSpace(message)
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return challengeChan
+}
+
+// answerChallenge gets a code from the ChallengeHandler, types it into the challenge and submits it.
+// The input is marked as answered with the code so that watchChallenge doesn't report the same challenge again
+func (bank *Bank) answerChallenge(ctx context.Context, challenge Challenge) error {
+	bank.logger.Info("Step-up authentication challenge", "message", challenge.Message)
+	if bank.challengeHandler == nil {
+		return fmt.Errorf("%w: %s", ErrChallengeRequired, challenge.Message)
+	}
+	code, err := bank.challengeHandler.Code(ctx, challenge)
+	if err != nil {
+		return &kindError{kind: ErrChallengeRequired, err: fmt.Errorf("challenge handler failed: %w", err)}
+	}
+	if err := dp.Run(ctx,
+		dp.SendKeys(challengeCodeSelector, strings.TrimSpace(code), dp.ByQuery, dp.NodeVisible),
+		dp.SetAttributeValue(challengeCodeSelector, "data-ingaugo-answered", strings.TrimSpace(code), dp.ByQuery, dp.NodeVisible),
+		dp.SendKeys(challengeCodeSelector, kb.Enter, dp.ByQuery, dp.NodeVisible),
+	); err != nil {
+		return fmt.Errorf("Chrome actions failed: %w", err)
+	}
+	bank.logger.Info("Submitted challenge code")
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/porjo/ingaugo"
+)
+
+type balanceOutput struct {
+	AccountNumber string `json:"accountNumber"`
+	Current       string `json:"current"`
+	Available     string `json:"available"`
+	Time          string `json:"time"`
+}
+
+func PrintBalances(w io.Writer, balances []ingaugo.Balance, output string) error {
+	switch output {
+	case "json":
+		out := make([]balanceOutput, 0, len(balances))
+		for _, b := range balances {
+			out = append(out, balanceOutput{
+				AccountNumber: b.AccountNumber,
+				Current:       b.Current.String(),
+				Available:     b.Available.String(),
+				Time:          b.Time.Format("2006-01-02T15:04:05Z07:00"),
+			})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(tw, "Account\tCurrent\tAvailable\t")
+		for _, b := range balances {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t\n", b.AccountNumber, b.Current, b.Available)
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("unknown output %q", output)
+}
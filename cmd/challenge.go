@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/porjo/ingaugo"
+)
+
+// stdinLine is a line read from stdin, or the error that ended reading
+type stdinLine struct {
+	text string
+	err  error
+}
+
+var (
+	stdinOnce  sync.Once
+	stdinLines chan stdinLine
+)
+
+// readStdinLines starts reading stdin on a single goroutine and returns the lines read. A prompt that is abandoned
+// when its context is done leaves the pending read to the next prompt, rather than a reader goroutine per prompt.
+// The channel is closed after the error that ends reading
+func readStdinLines() <-chan stdinLine {
+	stdinOnce.Do(func() {
+		stdinLines = make(chan stdinLine, 1)
+		go func() {
+			defer close(stdinLines)
+			reader := bufio.NewReader(os.Stdin)
+			for {
+				text, err := reader.ReadString('\n')
+				if err != nil {
+					stdinLines <- stdinLine{err: err}
+					return
+				}
+				stdinLines <- stdinLine{text: text}
+			}
+		}()
+	})
+	return stdinLines
+}
+
+// promptChallenge asks for the step-up authentication code on the terminal
+func promptChallenge(ctx context.Context, challenge ingaugo.Challenge) (string, error) {
+	lines := readStdinLines()
+	// discard anything typed before the prompt, e.g. in reply to an abandoned prompt
+	for discarding := true; discarding; {
+		select {
+		case line, ok := <-lines:
+			if !ok || line.err != nil {
+				return "", fmt.Errorf("reading verification code: %w", stdinErr(line, ok))
+			}
+		default:
+			discarding = false
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "%s\nVerification code: ", challenge.Message)
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case line, ok := <-lines:
+		if !ok || line.err != nil {
+			return "", fmt.Errorf("reading verification code: %w", stdinErr(line, ok))
+		}
+		return line.text, nil
+	}
+}
+
+// stdinErr returns the error that ended reading stdin, given a receive from a closed channel or an error line
+func stdinErr(line stdinLine, ok bool) error {
+	if !ok {
+		return io.EOF
+	}
+	return line.err
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,123 @@
+// Command keypad-capture captures the ING login keypad images and regenerates the reference
+// images used to recognise the keypad digits.
+//
+// It loads the login page, saves each keypad key as key-<position>.png in -dir, then asks which
+// digit each image shows and writes the labelled images to -templates as <digit>.png. Rebuild the
+// module afterwards to embed the new images
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/porjo/ingaugo"
+	"golang.org/x/exp/slog"
+)
+
+const digits = 10
+
+func main() {
+	wsURL := flag.String("ws-url", "", "WebSsocket URL e.g. ws://localhost:9222")
+	dir := flag.String("dir", "keypad-capture", "Directory to save the captured keypad images to")
+	templates := flag.String("templates", "internal/keypad/templates", "Directory to write the labelled reference images to")
+	label := flag.Bool("label", true, "Label the captured images and write them to -templates")
+	skipCapture := flag.Bool("skipCapture", false, "Label images previously saved in -dir instead of capturing new ones")
+	debug := flag.Bool("debug", false, "Output verbose logging")
+
+	flag.Parse()
+
+	logOpts := slog.HandlerOptions{Level: slog.LevelInfo}
+	if *debug {
+		logOpts.Level = slog.LevelDebug
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &logOpts))
+
+	if !*skipCapture {
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		bank, err := ingaugo.NewBank(ingaugo.WithLogger(logger), ingaugo.WithWebsocketURL(*wsURL))
+		if err != nil {
+			log.Fatal(err)
+		}
+		pngs, err := bank.CaptureKeypad(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(pngs) != digits {
+			log.Fatalf("Expected %d keypad images, found %d", digits, len(pngs))
+		}
+		if err := os.MkdirAll(*dir, 0755); err != nil {
+			log.Fatal(err)
+		}
+		for i, b := range pngs {
+			file := keyFile(*dir, i)
+			if err := os.WriteFile(file, b, 0644); err != nil {
+				log.Fatal(err)
+			}
+			logger.Info("Saved keypad image", "file", file)
+		}
+	}
+
+	if !*label {
+		return
+	}
+
+	labels, err := promptLabels(*dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for pos, digit := range labels {
+		b, err := os.ReadFile(keyFile(*dir, pos))
+		if err != nil {
+			log.Fatal(err)
+		}
+		file := filepath.Join(*templates, strconv.Itoa(digit)+".png")
+		if err := os.WriteFile(file, b, 0644); err != nil {
+			log.Fatal(err)
+		}
+		logger.Info("Wrote reference image", "file", file)
+	}
+}
+
+func keyFile(dir string, pos int) string {
+	return filepath.Join(dir, fmt.Sprintf("key-%d.png", pos))
+}
+
+// promptLabels asks which digit each captured image shows and returns the digit for each keypad position
+func promptLabels(dir string) ([]int, error) {
+	scanner := bufio.NewScanner(os.Stdin)
+	labels := make([]int, digits)
+	seen := make(map[int]int)
+	for pos := 0; pos < digits; pos++ {
+		fmt.Printf("Digit shown in %s: ", keyFile(dir, pos))
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("labelling aborted")
+		}
+		digit, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+		if err != nil || digit < 0 || digit >= digits {
+			fmt.Printf("Enter a single digit 0-9\n")
+			pos--
+			continue
+		}
+		if prev, ok := seen[digit]; ok {
+			fmt.Printf("Digit %d was already given to %s\n", digit, keyFile(dir, prev))
+			pos--
+			continue
+		}
+		seen[digit] = pos
+		labels[pos] = digit
+	}
+	return labels, nil
+}
@@ -1,14 +1,18 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/porjo/ingaugo"
+	"github.com/porjo/ingaugo/sqlitestore"
 	"golang.org/x/exp/slog"
 )
 
@@ -26,20 +30,75 @@ func (i *arrayFlags) Set(value string) error {
 var bank *ingaugo.Bank
 var logger *slog.Logger
 
+const usage = `Usage: %s [command] [flags]
+
+Commands:
+  transactions  Download transactions (default)
+  sync          Append new transactions to <account>.csv files
+  balances      Print account balances
+
+`
+
 func main() {
 
 	accounts := make(arrayFlags, 0)
 
-	wsURL := flag.String("ws-url", "", "WebSsocket URL e.g. ws://localhost:9222")
+	wsURL := flag.String("ws-url", "", "WebSsocket URL e.g. ws://localhost:9222 or http://localhost:9222")
 	clientNumber := flag.String("clientNumber", "", "Client number")
 	accessPin := flag.String("accessPin", "", "Access pin")
 	flag.Var(&accounts, "accountNumber", "Account number")
+	accountNames := make(arrayFlags, 0)
+	flag.Var(&accountNames, "accountName", "beancount/ledger account name for an account number e.g. 0909090909=Assets:ING:Savings")
+	balanceAssertions := flag.Bool("balanceAssertions", false, "Add balance assertions from the running balance to beancount/ledger output")
+	openAccounts := flag.Bool("openAccounts", false, "Open the accounts used at the start of beancount output, so that it can be checked on its own")
+	allAccounts := flag.Bool("allAccounts", false, "Download transactions for all accounts of the client")
 	days := flag.Int("days", 30, "Number of days of transactions")
-	format := flag.String("format", "csv", "transaction output format (csv,ofx,qif)")
+	fromDate := flag.String("from", "", "Start date (YYYY-MM-DD) of transactions. Overrides -days")
+	toDate := flag.String("to", "", "End date (YYYY-MM-DD) of transactions, inclusive. Defaults to today when -from is set")
+	format := flag.String("format", "csv", "transaction output format (csv,ofx,qif,beancount,ledger,json,ndjson)")
 	outputDir := flag.String("outputDir", "", "Directory to write CSV files. Defaults to current directory")
 	debug := flag.Bool("debug", false, "Output verbose logging")
+	timeout := flag.Duration("timeout", 60*time.Second, "Overall timeout for login and downloads")
+	tokenCache := flag.String("tokenCache", "", "File to cache the auth token in between runs. Encrypted when TOKEN_CACHE_KEY environment variable is set")
+	diagnosticsDir := flag.String("diagnosticsDir", "", "Directory to write a diagnostics bundle to when login fails")
+	proxy := flag.String("proxy", "", "Proxy URL for browser and API requests e.g. http://proxy:3128")
+	output := flag.String("output", "table", "balances output format (table,json)")
+	keypadTimeout := flag.Duration("keypadTimeout", 10*time.Second, "How long to wait for the login keypad to load")
+	browserPath := flag.String("browserPath", "", "Chrome or Chromium binary to launch. Defaults to the first headless-shell, Chromium or Chrome found")
+	headful := flag.Bool("headful", false, "Show the browser window instead of running headless")
+	userDataDir := flag.String("userDataDir", "", "Browser profile directory. Defaults to a temporary directory")
+	storeSpec := flag.String("store", "", "Store transactions, accounts and balances in a database instead of files e.g. sqlite:ingaugo.db")
+	merge := flag.Bool("merge", false, "Merge transactions into the existing CSV file instead of overwriting it, removing duplicates")
+	stateFile := flag.String("stateFile", "", "sync state file. Defaults to .ingaugo-sync.json in the output directory")
+	overlapDays := flag.Int("overlapDays", ingaugo.DefaultSyncOverlapDays, "Number of days before the last synced transaction that sync requests again")
+	workers := flag.Int("workers", 4, "Number of accounts to download concurrently")
+	loginRetries := flag.Int("loginRetries", 0, "Number of times to retry a failed login")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Flags:")
+		flag.PrintDefaults()
+	}
 
-	flag.Parse()
+	command := "transactions"
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command = args[0]
+		args = args[1:]
+	}
+	flag.CommandLine.Parse(args)
+
+	switch command {
+	case "transactions", "sync":
+	case "balances":
+		if *output != "table" && *output != "json" {
+			log.Fatalf("Unknown output %q", *output)
+		}
+	default:
+		fmt.Printf("Unknown command %q\n\n", command)
+		flag.Usage()
+		os.Exit(1)
+	}
 
 	if *clientNumber == "" {
 		fmt.Printf("-clientNumber is required\n\n")
@@ -67,8 +126,39 @@ func main() {
 		}
 	}
 
+	if *merge && *format != ingaugo.CSV {
+		log.Fatal("-merge requires -format csv")
+	}
+	if *merge && *storeSpec != "" {
+		log.Fatal("-merge can't be used with -store")
+	}
+
+	writeOpts := ingaugo.WriteOptions{
+		Accounts:          make(map[string]string),
+		BalanceAssertions: *balanceAssertions,
+		OpenAccounts:      *openAccounts,
+	}
+	for _, an := range accountNames {
+		number, name, ok := strings.Cut(an, "=")
+		if !ok || number == "" || name == "" {
+			log.Fatalf("Invalid -accountName %q, expected <account number>=<name>", an)
+		}
+		writeOpts.Accounts[number] = name
+	}
+
+	var from, to time.Time
+	if *fromDate != "" {
+		var err error
+		from, to, err = parseRange(*fromDate, *toDate)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else if *toDate != "" {
+		log.Fatal("-to requires -from")
+	}
+
 	// create a timeout as a safety net to prevent any infinite wait loops
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
 	defer cancel()
 
 	logOpts := slog.HandlerOptions{}
@@ -77,33 +167,185 @@ func main() {
 	} else {
 		logOpts.Level = slog.LevelInfo
 	}
-	logger = slog.New(slog.NewTextHandler(os.Stdout, &logOpts))
+	logOutput := os.Stdout
+	if command == "balances" {
+		// keep stdout clean for the balances output
+		logOutput = os.Stderr
+	}
+	logger = slog.New(slog.NewTextHandler(logOutput, &logOpts))
+
+	var db *sqlitestore.Store
+	if *storeSpec != "" {
+		var err error
+		db, err = openStore(ctx, *storeSpec)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+	}
+
+	opts := []ingaugo.Option{
+		ingaugo.WithLogger(logger),
+		ingaugo.WithWebsocketURL(*wsURL),
+		ingaugo.WithKeypadTimeout(*keypadTimeout),
+		ingaugo.WithLoginRetries(*loginRetries, 2*time.Second),
+	}
+	if *proxy != "" {
+		opts = append(opts, ingaugo.WithProxy(*proxy))
+	}
+	if *browserPath != "" {
+		opts = append(opts, ingaugo.WithBrowserPath(*browserPath))
+	}
+	if *headful {
+		opts = append(opts, ingaugo.WithHeadless(false))
+	}
+	if *userDataDir != "" {
+		opts = append(opts, ingaugo.WithUserDataDir(*userDataDir))
+	}
+	if isTerminal(os.Stdin) {
+		opts = append(opts, ingaugo.WithChallengeHandler(ingaugo.ChallengeHandlerFunc(promptChallenge)))
+	}
+	if *diagnosticsDir != "" {
+		opts = append(opts, ingaugo.WithDiagnosticsDir(*diagnosticsDir))
+	}
+	if *tokenCache != "" {
+		store, err := ingaugo.NewFileTokenStore(*tokenCache, os.Getenv("TOKEN_CACHE_KEY"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, ingaugo.WithTokenStore(store))
+	}
 
 	var err error
-	bank, err = ingaugo.NewBank(logger, *wsURL)
+	bank, err = ingaugo.NewBank(opts...)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	logger.Info("Fetching auth token...")
-	token, err := bank.Login(ctx, *clientNumber, *accessPin)
+	session, err := bank.Login(ctx, *clientNumber, *accessPin)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	if *debug {
-		logger.Debug("token returned", "token", token)
+		logger.Debug("token returned", "token", session.Token())
+	}
+
+	if command == "balances" {
+		balances, err := session.Balances(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if db != nil {
+			if err := db.AddBalances(ctx, balances); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if err := PrintBalances(os.Stdout, balances, *output); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
-	for _, acct := range accounts {
-		err := GetTransactions(*days, *format, acct, token, *outputDir)
+	// accounts are discovered for -allAccounts, and with -store to record them. Only -allAccounts needs them
+	// to continue, as with -store alone the accounts to download were named explicitly
+	if *allAccounts || db != nil {
+		discovered, err := session.Accounts(ctx)
+		switch {
+		case err != nil && *allAccounts:
+			log.Fatal(err)
+		case err != nil:
+			logger.Warn("Account discovery failed, accounts and balances won't be stored", "error", err)
+		default:
+			for _, a := range discovered {
+				logger.Info("Found account", "accountNumber", a.Number, "product", a.ProductName, "nickname", a.Nickname)
+				if *allAccounts && !contains(accounts, a.Number) {
+					accounts = append(accounts, a.Number)
+				}
+			}
+			if db != nil {
+				if err := StoreAccounts(ctx, db, discovered); err != nil {
+					log.Fatal(err)
+				}
+			}
+		}
+	}
+
+	var state *ingaugo.SyncState
+	if command == "sync" {
+		if *stateFile == "" {
+			*stateFile = filepath.Join(*outputDir, ".ingaugo-sync.json")
+		}
+		state, err = ingaugo.LoadSyncState(*stateFile)
 		if err != nil {
 			log.Fatal(err)
 		}
 	}
+	var store ingaugo.TransactionStore = ingaugo.NewCSVStore(*outputDir)
+	if db != nil {
+		store = db
+	}
+	syncOpts := ingaugo.SyncOptions{OverlapDays: *overlapDays, InitialDays: *days}
+
+	results := ingaugo.ForEachAccount(ctx, accounts, *workers, func(ctx context.Context, acct string) error {
+		if command == "sync" {
+			return SyncTransactions(ctx, acct, session, state, store, syncOpts)
+		}
+		if db != nil {
+			return StoreTransactions(ctx, *days, from, to, acct, session, db)
+		}
+		return GetTransactions(ctx, *days, from, to, *format, acct, session, *outputDir, *merge, writeOpts)
+	})
+	if state != nil {
+		if err := state.Save(*stateFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			logger.Error("Account failed", "accountNumber", r.AccountNumber, "error", r.Err)
+		}
+	}
+	logger.Info("Summary", "accounts", len(results), "succeeded", len(results)-failed, "failed", failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
 }
 
-func GetTransactions(days int, format string, accountNumber, token, outputDir string) error {
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRange returns midnight at the start of fromDate until midnight at the end of toDate in Sydney time
+func parseRange(fromDate, toDate string) (time.Time, time.Time, error) {
+	loc, err := time.LoadLocation("Australia/Sydney")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	from, err := time.ParseInLocation("2006-01-02", fromDate, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid -from date: %w", err)
+	}
+	to := time.Now().In(loc)
+	if toDate != "" {
+		to, err = time.ParseInLocation("2006-01-02", toDate, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid -to date: %w", err)
+		}
+	}
+	to = time.Date(to.Year(), to.Month(), to.Day()+1, 0, 0, 0, 0, loc)
+	return from, to, nil
+}
+
+func GetTransactions(ctx context.Context, days int, from, to time.Time, format string, accountNumber string, session *ingaugo.Session, outputDir string, merge bool, writeOpts ingaugo.WriteOptions) error {
 	logger.Info("Fetching transactions for account", "accountNumber", accountNumber)
 	var f ingaugo.Format
 	switch format {
@@ -113,11 +355,20 @@ func GetTransactions(days int, format string, accountNumber, token, outputDir st
 		f = ingaugo.QIF
 	case ingaugo.CSV:
 		f = ingaugo.CSV
+	case ingaugo.Beancount, ingaugo.Ledger, ingaugo.JSON, ingaugo.NDJSON:
+		// generated from the CSV export, which includes the running balance
+		f = ingaugo.CSV
 	default:
 		logger.Warn(fmt.Sprintf("Unknown format %q supplied, defaulting to %q", format, ingaugo.CSV))
 		f = ingaugo.CSV
 	}
-	trans, err := bank.GetTransactionsDays(days, f, accountNumber, token)
+	var trans []byte
+	var err error
+	if from.IsZero() {
+		trans, err = session.TransactionsDays(ctx, days, f, accountNumber)
+	} else {
+		trans, err = session.TransactionsRange(ctx, from, to, f, accountNumber)
+	}
 	if err != nil {
 		return err
 	}
@@ -126,6 +377,18 @@ func GetTransactions(days int, format string, accountNumber, token, outputDir st
 	if outputDir != "" {
 		file = outputDir + "/" + file
 	}
+	if merge {
+		trans, err = mergeTransactions(file, trans)
+		if err != nil {
+			return err
+		}
+	}
+	if isClientFormat(format) {
+		trans, err = convertTransactions(trans, format, accountNumber, writeOpts)
+		if err != nil {
+			return err
+		}
+	}
 	logger.Info("Writing transaction file", "file", file)
 	if err := os.WriteFile(file, trans, 0666); err != nil {
 		return err
@@ -133,3 +396,64 @@ func GetTransactions(days int, format string, accountNumber, token, outputDir st
 
 	return nil
 }
+
+func SyncTransactions(ctx context.Context, accountNumber string, session *ingaugo.Session, state *ingaugo.SyncState, store ingaugo.TransactionStore, opts ingaugo.SyncOptions) error {
+	added, err := session.Sync(ctx, accountNumber, state, store, opts)
+	if err != nil {
+		return err
+	}
+	logger.Info("Synced account", "accountNumber", accountNumber, "new", len(added))
+	return nil
+}
+
+// mergeTransactions merges the CSV export trans into the transactions of the CSV file, if it exists
+func mergeTransactions(file string, trans []byte) ([]byte, error) {
+	fetched, err := ingaugo.ParseCSV(bytes.NewReader(trans))
+	if err != nil {
+		return nil, err
+	}
+	var existing []ingaugo.Transaction
+	f, err := os.Open(file)
+	if err == nil {
+		existing, err = ingaugo.ParseCSV(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", file, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	merged := ingaugo.Merge(existing, fetched)
+	logger.Info("Merged transactions", "file", file, "existing", len(existing), "new", len(merged)-len(existing))
+	var buf bytes.Buffer
+	if err := ingaugo.WriteCSV(&buf, merged); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// convertTransactions converts the CSV export trans to a client-side format
+func convertTransactions(trans []byte, format string, accountNumber string, writeOpts ingaugo.WriteOptions) ([]byte, error) {
+	txns, err := ingaugo.ParseCSV(bytes.NewReader(trans))
+	if err != nil {
+		return nil, err
+	}
+	for i := range txns {
+		txns[i].Account = accountNumber
+	}
+	var buf bytes.Buffer
+	if err := ingaugo.WriteTransactions(&buf, format, txns, writeOpts); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// isClientFormat reports whether format is generated from the CSV export rather than exported by ING
+func isClientFormat(format string) bool {
+	switch format {
+	case ingaugo.Beancount, ingaugo.Ledger, ingaugo.JSON, ingaugo.NDJSON:
+		return true
+	}
+	return false
+}
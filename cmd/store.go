@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/porjo/ingaugo"
+	"github.com/porjo/ingaugo/sqlitestore"
+)
+
+// openStore opens the database given by the -store flag e.g. sqlite:ingaugo.db
+func openStore(ctx context.Context, spec string) (*sqlitestore.Store, error) {
+	if !strings.HasPrefix(spec, "sqlite:") {
+		return nil, fmt.Errorf("unsupported store %q, expected sqlite:<path>", spec)
+	}
+	path := strings.TrimPrefix(spec, "sqlite:")
+	if path == "" {
+		return nil, fmt.Errorf("store %q has no path", spec)
+	}
+	return sqlitestore.Open(ctx, path)
+}
+
+// StoreAccounts upserts the accounts and a snapshot of their balances
+func StoreAccounts(ctx context.Context, db *sqlitestore.Store, accounts []ingaugo.Account) error {
+	if err := db.UpsertAccounts(ctx, accounts); err != nil {
+		return err
+	}
+	return db.AddBalances(ctx, ingaugo.AccountBalances(accounts, time.Now()))
+}
+
+// StoreTransactions fetches and upserts the transactions of the account
+func StoreTransactions(ctx context.Context, days int, from, to time.Time, accountNumber string, session *ingaugo.Session, db *sqlitestore.Store) error {
+	logger.Info("Fetching transactions for account", "accountNumber", accountNumber)
+	var txns []ingaugo.Transaction
+	var err error
+	if from.IsZero() {
+		txns, err = session.Transactions(ctx, days, ingaugo.CSV, accountNumber)
+	} else {
+		var trans []byte
+		trans, err = session.TransactionsRange(ctx, from, to, ingaugo.CSV, accountNumber)
+		if err == nil {
+			txns, err = ingaugo.ParseCSV(bytes.NewReader(trans))
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if err := db.Append(ctx, accountNumber, txns); err != nil {
+		return err
+	}
+	logger.Info("Stored transactions", "accountNumber", accountNumber, "count", len(txns))
+	return nil
+}
@@ -0,0 +1,162 @@
+package ingaugo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	dp "github.com/chromedp/chromedp"
+)
+
+// diagnosticsTimeout bounds how long collecting diagnostics from the browser may take
+const diagnosticsTimeout = 15 * time.Second
+
+// diagnostics records browser events during a login so that they can be written out if the login fails
+type diagnostics struct {
+	mu      sync.Mutex
+	console []string
+	network []string
+}
+
+func (d *diagnostics) listen(ev interface{}) {
+	switch ev := ev.(type) {
+	case *runtime.EventConsoleAPICalled:
+		args := make([]string, 0, len(ev.Args))
+		for _, arg := range ev.Args {
+			if arg.Value != nil {
+				args = append(args, string(arg.Value))
+			} else {
+				args = append(args, arg.Description)
+			}
+		}
+		d.addConsole(fmt.Sprintf("%s %s", ev.Type, strings.Join(args, " ")))
+	case *runtime.EventExceptionThrown:
+		text := ev.ExceptionDetails.Text
+		if ev.ExceptionDetails.Exception != nil {
+			text += " " + ev.ExceptionDetails.Exception.Description
+		}
+		d.addConsole("exception " + text)
+	case *network.EventRequestWillBeSent:
+		d.addNetwork(fmt.Sprintf("request %s %s %s", ev.RequestID, ev.Request.Method, truncateURL(ev.Request.URL)))
+	case *network.EventResponseReceived:
+		d.addNetwork(fmt.Sprintf("response %s %d %s", ev.RequestID, ev.Response.Status, truncateURL(ev.Response.URL)))
+	case *network.EventLoadingFailed:
+		d.addNetwork(fmt.Sprintf("failed %s %s", ev.RequestID, ev.ErrorText))
+	}
+}
+
+func (d *diagnostics) addConsole(line string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.console = append(d.console, time.Now().Format(time.RFC3339Nano)+" "+line)
+}
+
+func (d *diagnostics) addNetwork(line string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.network = append(d.network, time.Now().Format(time.RFC3339Nano)+" "+line)
+}
+
+// truncateURL shortens data URLs such as the keypad images, which would otherwise swamp the log
+func truncateURL(u string) string {
+	if len(u) > 200 {
+		return u[:200] + "..."
+	}
+	return u
+}
+
+// writeDiagnostics writes a bundle describing a failed login to a new directory under the diagnostics directory:
+// the error, a full-page screenshot, the page HTML, the keypad images, and the console and network logs.
+// Collection is best effort; parts that can't be collected are skipped
+func (bank *Bank) writeDiagnostics(browserCtx context.Context, diag *diagnostics, loginErr error) {
+	dir := filepath.Join(bank.diagnosticsDir, "login-"+time.Now().Format("20060102-150405.000"))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		bank.logger.Warn("Creating diagnostics directory failed", "error", err)
+		return
+	}
+	write := func(name string, data []byte) {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			bank.logger.Warn("Writing diagnostics failed", "file", name, "error", err)
+		}
+	}
+
+	write("error.txt", []byte(loginErr.Error()+"\n"))
+
+	diag.mu.Lock()
+	write("console.log", []byte(strings.Join(diag.console, "\n")+"\n"))
+	write("network.log", []byte(strings.Join(diag.network, "\n")+"\n"))
+	diag.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(browserCtx, diagnosticsTimeout)
+	defer cancel()
+
+	var screenshot []byte
+	if err := dp.Run(ctx, dp.FullScreenshot(&screenshot, 100)); err != nil {
+		bank.logger.Warn("Capturing screenshot failed", "error", err)
+	} else {
+		write("screenshot.png", screenshot)
+	}
+
+	var html string
+	if err := dp.Run(ctx, dp.OuterHTML("html", &html, dp.ByQuery)); err != nil {
+		bank.logger.Warn("Capturing page HTML failed", "error", err)
+	} else {
+		write("page.html", []byte(html))
+	}
+
+	var srcs []string
+	if err := dp.Run(ctx, dp.Evaluate(`Array.from(document.querySelectorAll('.pin > img')).map(img => img.getAttribute('src') || '')`, &srcs)); err != nil {
+		bank.logger.Warn("Capturing keypad images failed", "error", err)
+	}
+	for i, src := range srcs {
+		b, err := decodeDataURL(src)
+		if err != nil {
+			bank.logger.Warn("Decoding keypad image failed", "position", i, "error", err)
+			continue
+		}
+		write(fmt.Sprintf("keypad-%d.png", i), b)
+	}
+
+	bank.logger.Info("Wrote login diagnostics", "dir", dir)
+}
+
+// detachedContext carries the values of its parent but is never cancelled
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+// cancelOnDone calls cancel when ctx is done, until stop is called
+func cancelOnDone(ctx context.Context, cancel context.CancelFunc) (stop func()) {
+	stopChan := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			cancel()
+		case <-stopChan:
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(stopChan) })
+	}
+}
+
+// linkedContext returns a child of parent that is also cancelled when ctx is done
+func linkedContext(parent, ctx context.Context) (context.Context, context.CancelFunc) {
+	child, cancel := context.WithCancel(parent)
+	stop := cancelOnDone(ctx, cancel)
+	return child, func() {
+		stop()
+		cancel()
+	}
+}
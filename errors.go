@@ -0,0 +1,58 @@
+package ingaugo
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidCredentials is returned when ING rejects the client number or access pin
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrKeypadNotRecognized is returned when the login keypad images can't be matched to digits
+	ErrKeypadNotRecognized = errors.New("keypad not recognized")
+	// ErrTokenExpired is returned when the API rejects the authentication token
+	ErrTokenExpired = errors.New("token expired")
+	// ErrAccountNotFound is returned when the API doesn't know the account number
+	ErrAccountNotFound = errors.New("account not found")
+	// ErrChallengeRequired is returned when ING presents a step-up authentication challenge that couldn't be answered
+	ErrChallengeRequired = errors.New("step-up authentication required")
+	// ErrBankUnavailable is returned when ING can't be reached or responds with a server error
+	ErrBankUnavailable = errors.New("bank unavailable")
+)
+
+// HTTPError is returned when an ING API call responds with an unexpected status code.
+// Where the status code has a known meaning, errors.Is matches the corresponding sentinel error e.g. ErrTokenExpired
+type HTTPError struct {
+	URL        string
+	StatusCode int
+	Body       []byte
+
+	err error
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("error fetching %s. Status code: %d", e.URL, e.StatusCode)
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.err
+}
+
+// kindError marks err as being of the kind of a sentinel error, while keeping err in the chain
+// so that e.g. context errors can still be detected
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return fmt.Sprintf("%s: %s", e.kind, e.err)
+}
+
+func (e *kindError) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *kindError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,10 @@
+package ingaugo
+
+// FindBrowser exposes findBrowser to the external tests, which skip browser tests when no browser is installed
+var FindBrowser = findBrowser
+
+// SyncWindow exposes syncWindow to the external tests
+var SyncWindow = syncWindow
+
+// LoadStoredToken exposes storedToken to the external tests
+var LoadStoredToken = (*Bank).storedToken
@@ -0,0 +1,64 @@
+package ingaugo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+)
+
+// AssignIDs sets the ID of each transaction to a fingerprint of its date, amount, description and running balance,
+// and its occurrence among identical transactions, so that e.g. two identical coffees on one day get different IDs.
+// The parsers call it for each export, so the same transaction gets the same ID in overlapping downloads in the same
+// format. Pass all transactions of an export at once, since identical transactions are numbered within txns
+func AssignIDs(txns []Transaction) {
+	occurrences := make(map[string]int)
+	for i := range txns {
+		key := transactionKey(txns[i])
+		n := occurrences[key]
+		occurrences[key]++
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d", key, n)))
+		txns[i].ID = hex.EncodeToString(sum[:8])
+	}
+}
+
+func transactionKey(t Transaction) string {
+	balance := ""
+	if t.Balance != nil {
+		balance = t.Balance.String()
+	}
+	return fmt.Sprintf("%s|%s|%s|%s", t.Date.Format("2006-01-02"), t.Amount, t.Description, balance)
+}
+
+// Dedupe returns txns without the transactions whose ID appeared earlier in txns, keeping the order.
+// Transactions without an ID are always kept
+func Dedupe(txns []Transaction) []Transaction {
+	seen := make(map[string]bool)
+	deduped := make([]Transaction, 0, len(txns))
+	for _, t := range txns {
+		if t.ID != "" {
+			if seen[t.ID] {
+				continue
+			}
+			seen[t.ID] = true
+		}
+		deduped = append(deduped, t)
+	}
+	return deduped
+}
+
+// Merge combines the transactions of overlapping downloads, removing duplicates with Dedupe. The result is
+// oldest first; transactions on the same date keep their order, with those of earlier downloads first
+func Merge(downloads ...[]Transaction) []Transaction {
+	all := make([]Transaction, 0)
+	for _, txns := range downloads {
+		txns = append([]Transaction(nil), txns...)
+		chronological(txns)
+		all = append(all, txns...)
+	}
+	all = Dedupe(all)
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Date.Before(all[j].Date)
+	})
+	return all
+}
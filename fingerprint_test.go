@@ -0,0 +1,177 @@
+package ingaugo_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/porjo/ingaugo"
+	"github.com/porjo/ingaugo/ingtest"
+)
+
+// history returns 15 days of transactions oldest first, with running balances. Day 7 has two identical coffees
+func history() []ingaugo.Transaction {
+	var txns []ingaugo.Transaction
+	balance := ingaugo.Amount(100000)
+	add := func(day int, amount ingaugo.Amount, description string) {
+		balance += amount
+		txns = append(txns, ingaugo.Transaction{Date: date(2024, 3, day), Amount: amount, Description: description, Balance: amountPtr(balance)})
+	}
+	for day := 1; day <= 15; day++ {
+		add(day, -1000-ingaugo.Amount(day), "Groceries")
+		if day == 7 {
+			add(day, -450, "Coffee")
+			add(day, -450, "Coffee")
+		}
+	}
+	return txns
+}
+
+// export returns the transactions dated from day first to day last as ING exports them, newest first,
+// parsed back from format
+func export(t *testing.T, format ingaugo.Format, txns []ingaugo.Transaction, first, last int) []ingaugo.Transaction {
+	t.Helper()
+	var window []ingaugo.Transaction
+	for i := len(txns) - 1; i >= 0; i-- {
+		if day := txns[i].Date.Day(); day >= first && day <= last {
+			window = append(window, txns[i])
+		}
+	}
+	data, err := ingtest.Render(format, testAccountNumber, window)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ingaugo.ParseTransactions(format, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func coffeeIDs(txns []ingaugo.Transaction) []string {
+	var ids []string
+	for _, txn := range txns {
+		if txn.Description == "Coffee" {
+			ids = append(ids, txn.ID)
+		}
+	}
+	return ids
+}
+
+// checkMerged checks that merged holds every transaction of want once, oldest first
+func checkMerged(t *testing.T, merged, want []ingaugo.Transaction) {
+	t.Helper()
+	if len(merged) != len(want) {
+		t.Fatalf("merged %d transactions, want %d", len(merged), len(want))
+	}
+	seen := make(map[string]bool)
+	for i, txn := range merged {
+		if seen[txn.ID] {
+			t.Errorf("transaction %d: duplicate ID %s", i, txn.ID)
+		}
+		seen[txn.ID] = true
+		if !txn.Date.Equal(want[i].Date) || txn.Amount != want[i].Amount || txn.Description != want[i].Description {
+			t.Errorf("transaction %d = %s %s %q, want %s %s %q", i,
+				txn.Date.Format("2006-01-02"), txn.Amount, txn.Description,
+				want[i].Date.Format("2006-01-02"), want[i].Amount, want[i].Description)
+		}
+	}
+}
+
+func TestAssignIDsIdenticalTransactions(t *testing.T) {
+	for _, format := range []ingaugo.Format{ingaugo.CSV, ingaugo.OFX, ingaugo.QIF} {
+		t.Run(format, func(t *testing.T) {
+			txns := history()
+			first := coffeeIDs(export(t, format, txns, 1, 15))
+			if len(first) != 2 || first[0] == first[1] {
+				t.Fatalf("coffee IDs = %v, want two different IDs", first)
+			}
+			// a later download of an overlapping window gives the same IDs
+			second := coffeeIDs(export(t, format, txns, 5, 10))
+			if len(second) != 2 || !sameSet(first, second) {
+				t.Errorf("coffee IDs = %v in overlapping download, want %v", second, first)
+			}
+		})
+	}
+}
+
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[string]int)
+	for _, s := range a {
+		set[s]++
+	}
+	for _, s := range b {
+		set[s]--
+	}
+	for _, n := range set {
+		if n != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMergeOverlappingDownloads(t *testing.T) {
+	for _, format := range []ingaugo.Format{ingaugo.CSV, ingaugo.OFX, ingaugo.QIF} {
+		t.Run(format, func(t *testing.T) {
+			txns := history()
+			merged := ingaugo.Merge(export(t, format, txns, 1, 10), export(t, format, txns, 5, 15))
+			checkMerged(t, merged, txns)
+
+			// the order of the downloads doesn't matter
+			merged = ingaugo.Merge(export(t, format, txns, 5, 15), export(t, format, txns, 1, 10))
+			checkMerged(t, merged, txns)
+		})
+	}
+}
+
+func TestMergeSingleDayDownload(t *testing.T) {
+	// a download of a single day has no dates to tell its order, so the running balances of the CSV export are used.
+	// OFX and QIF exports have no balances and are taken to be newest first
+	for _, format := range []ingaugo.Format{ingaugo.CSV, ingaugo.OFX, ingaugo.QIF} {
+		t.Run(format, func(t *testing.T) {
+			txns := history()
+			merged := ingaugo.Merge(export(t, format, txns, 1, 6), export(t, format, txns, 7, 7), export(t, format, txns, 8, 15))
+			checkMerged(t, merged, txns)
+		})
+	}
+}
+
+func TestMergeStoreFile(t *testing.T) {
+	txns := history()
+
+	// the store file is written oldest first, while ING exports are newest first
+	var file bytes.Buffer
+	if err := ingaugo.WriteCSV(&file, ingaugo.Merge(export(t, ingaugo.CSV, txns, 1, 8))); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := ingaugo.ParseCSV(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stored[0].Date.Before(stored[len(stored)-1].Date) {
+		t.Fatal("store file is not oldest first")
+	}
+
+	merged := ingaugo.Merge(stored, export(t, ingaugo.CSV, txns, 6, 15))
+	checkMerged(t, merged, txns)
+}
+
+func TestDedupe(t *testing.T) {
+	txns := []ingaugo.Transaction{
+		{ID: "a", Description: "first"},
+		{ID: "b"},
+		{ID: "a", Description: "second"},
+		{Description: "no ID"},
+		{Description: "no ID"},
+	}
+	deduped := ingaugo.Dedupe(txns)
+	if len(deduped) != 4 {
+		t.Fatalf("Dedupe kept %d transactions, want 4", len(deduped))
+	}
+	if deduped[0].Description != "first" {
+		t.Errorf("Dedupe kept %q, want the first occurrence", deduped[0].Description)
+	}
+}
@@ -0,0 +1,52 @@
+package ingaugo
+
+import (
+	"context"
+	"sync"
+)
+
+// AccountResult is the outcome for one account of ForEachAccount. Err is set if the account failed
+type AccountResult struct {
+	AccountNumber string
+	Err           error
+}
+
+// ForEachAccount calls fn for each account number, running up to workers calls concurrently. A failed account
+// doesn't stop the others. It returns a result for each account in the order of accountNumbers
+func ForEachAccount(ctx context.Context, accountNumbers []string, workers int, fn func(ctx context.Context, accountNumber string) error) []AccountResult {
+	if workers < 1 {
+		workers = 1
+	}
+	results := make([]AccountResult, len(accountNumbers))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(accountNumbers); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				// ctx may have been cancelled while the job was waiting for a worker
+				if err := ctx.Err(); err != nil {
+					results[i].Err = err
+					continue
+				}
+				results[i].Err = fn(ctx, accountNumbers[i])
+			}
+		}()
+	}
+
+	for i, accountNumber := range accountNumbers {
+		results[i].AccountNumber = accountNumber
+		if err := ctx.Err(); err != nil {
+			// don't start any more accounts, but still report them
+			results[i].Err = err
+			continue
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
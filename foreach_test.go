@@ -0,0 +1,91 @@
+package ingaugo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/porjo/ingaugo"
+)
+
+func TestForEachAccount(t *testing.T) {
+	const workers = 3
+	var accountNumbers []string
+	for i := 0; i < 10; i++ {
+		accountNumbers = append(accountNumbers, fmt.Sprintf("09090909%02d", i))
+	}
+	errFailed := errors.New("download failed")
+
+	var inFlight, maxInFlight int32
+	results := ingaugo.ForEachAccount(context.Background(), accountNumbers, workers, func(ctx context.Context, accountNumber string) error {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if accountNumber == accountNumbers[4] {
+			return errFailed
+		}
+		return nil
+	})
+
+	if max := atomic.LoadInt32(&maxInFlight); max > workers {
+		t.Errorf("%d accounts ran concurrently, want at most %d", max, workers)
+	}
+	if len(results) != len(accountNumbers) {
+		t.Fatalf("got %d results, want %d", len(results), len(accountNumbers))
+	}
+	for i, result := range results {
+		if result.AccountNumber != accountNumbers[i] {
+			t.Errorf("result %d is for account %s, want %s", i, result.AccountNumber, accountNumbers[i])
+		}
+		var wantErr error
+		if i == 4 {
+			wantErr = errFailed
+		}
+		if result.Err != wantErr {
+			t.Errorf("result %d: error = %v, want %v", i, result.Err, wantErr)
+		}
+	}
+}
+
+func TestForEachAccountCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	accountNumbers := []string{"a", "b", "c", "d", "e"}
+
+	var mu sync.Mutex
+	started := make(map[string]bool)
+	results := ingaugo.ForEachAccount(ctx, accountNumbers, 1, func(ctx context.Context, accountNumber string) error {
+		mu.Lock()
+		started[accountNumber] = true
+		mu.Unlock()
+		if accountNumber == "b" {
+			cancel()
+		}
+		return nil
+	})
+
+	for i, result := range results {
+		if i <= 1 {
+			if result.Err != nil || !started[result.AccountNumber] {
+				t.Errorf("account %s: started %t, error = %v, want started without error", result.AccountNumber, started[result.AccountNumber], result.Err)
+			}
+			continue
+		}
+		if started[result.AccountNumber] {
+			t.Errorf("account %s started after ctx was cancelled", result.AccountNumber)
+		}
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("account %s: error = %v, want context.Canceled", result.AccountNumber, result.Err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package ingtest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// loginPage renders a minimal version of the ING login page. Keypad position i shows digit perm[i].
+// Like the real page, the keypad fires 'ing-keypad-loading-end' twice once the images are shown.
+// When the token endpoint asks for a challenge, the page shows a verification code form that calls it again with the code.
+// If the code is rejected, the form is cleared and shows the message from the token endpoint
+func loginPage(perm []int, templates []string) string {
+	var keys strings.Builder
+	digits := make([]string, len(perm))
+	for i, d := range perm {
+		fmt.Fprintf(&keys, `<img class="uia-pin-%d" src="data:image/png;base64,%s" width="90" height="55">`, i, templates[d])
+		digits[i] = fmt.Sprint(d)
+	}
+
+	return `<!DOCTYPE html>
+<html>
+<head><title>ING Secure Banking</title></head>
+<body>
+<div id="loginInput">
+<input id="cifField" type="text" name="cif">
+<div id="pinDisplay"></div>
+<div class="pin">` + keys.String() + `</div>
+<button id="login-btn" type="button">Log in</button>
+</div>
+<form id="challenge" hidden>
+<p id="challengeMessage">Enter the verification code we sent to your mobile</p>
+<input id="challengeCode" type="text" autocomplete="one-time-code">
+<button type="submit">Verify</button>
+</form>
+<script>
+const digits = [` + strings.Join(digits, ",") + `];
+let pin = '';
+document.querySelectorAll('.pin > img').forEach((img, i) => {
+	img.addEventListener('click', () => {
+		pin += digits[i];
+		document.getElementById('pinDisplay').textContent = '*'.repeat(pin.length);
+	});
+});
+function issueToken(code) {
+	return fetch('` + tokenPath + `', {
+		method: 'POST',
+		headers: {'Content-Type': 'application/json'},
+		body: JSON.stringify({ClientNumber: document.getElementById('cifField').value, AccessPin: pin, Code: code}),
+	}).then(resp => resp.json());
+}
+document.getElementById('login-btn').addEventListener('click', () => {
+	issueToken('').then(resp => {
+		if (resp.ChallengeRequired) {
+			document.getElementById('loginInput').hidden = true;
+			document.getElementById('challenge').hidden = false;
+		}
+	});
+});
+document.getElementById('challenge').addEventListener('submit', (ev) => {
+	ev.preventDefault();
+	issueToken(document.getElementById('challengeCode').value).then(resp => {
+		if (resp.ChallengeRequired) {
+			document.getElementById('challengeMessage').textContent = resp.ChallengeMessage;
+			document.getElementById('challengeCode').value = '';
+		}
+	});
+});
+window.addEventListener('load', () => {
+	setTimeout(() => document.dispatchEvent(new Event('ing-keypad-loading-end')), 50);
+	setTimeout(() => document.dispatchEvent(new Event('ing-keypad-loading-end')), 100);
+});
+</script>
+</body>
+</html>
+`
+}
@@ -0,0 +1,57 @@
+package ingtest
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+
+	"github.com/porjo/ingaugo"
+)
+
+// Render produces an export in the layout ING uses for format
+func Render(format, accountNumber string, txns []ingaugo.Transaction) ([]byte, error) {
+	switch format {
+	case ingaugo.CSV:
+		var buf bytes.Buffer
+		err := ingaugo.WriteCSV(&buf, txns)
+		return buf.Bytes(), err
+	case ingaugo.OFX:
+		return renderOFX(accountNumber, txns), nil
+	case ingaugo.QIF:
+		return renderQIF(txns), nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+func renderOFX(accountNumber string, txns []ingaugo.Transaction) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("OFXHEADER:100\r\nDATA:OFXSGML\r\nVERSION:102\r\n\r\n")
+	buf.WriteString("<OFX>\r\n<BANKMSGSRSV1>\r\n<STMTTRNRS>\r\n<STMTRS>\r\n<CURDEF>AUD\r\n")
+	fmt.Fprintf(&buf, "<BANKACCTFROM>\r\n<BANKID>923100\r\n<ACCTID>%s\r\n<ACCTTYPE>SAVINGS\r\n</BANKACCTFROM>\r\n", accountNumber)
+	buf.WriteString("<BANKTRANLIST>\r\n")
+	for i, t := range txns {
+		trnType := "CREDIT"
+		if t.Amount < 0 {
+			trnType = "DEBIT"
+		}
+		name := t.Description
+		if len(name) > 32 {
+			name = name[:32]
+		}
+		// OFX values are escaped like XML character data
+		name, memo := html.EscapeString(name), html.EscapeString(t.Description)
+		fmt.Fprintf(&buf, "<STMTTRN>\r\n<TRNTYPE>%s\r\n<DTPOSTED>%s\r\n<TRNAMT>%s\r\n<FITID>%d\r\n<NAME>%s\r\n<MEMO>%s\r\n</STMTTRN>\r\n",
+			trnType, t.Date.Format("20060102"), t.Amount, i+1, name, memo)
+	}
+	buf.WriteString("</BANKTRANLIST>\r\n</STMTRS>\r\n</STMTTRNRS>\r\n</BANKMSGSRSV1>\r\n</OFX>\r\n")
+	return buf.Bytes()
+}
+
+func renderQIF(txns []ingaugo.Transaction) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("!Type:Bank\n")
+	for _, t := range txns {
+		fmt.Fprintf(&buf, "D%s\nT%s\nP%s\n^\n", t.Date.Format("02/01/2006"), t.Amount, t.Description)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,281 @@
+// Package ingtest provides a fake ING server for testing code that uses ingaugo without network access.
+//
+// The fake serves a login page with a randomised keypad built from the real keypad images, the token
+// issue endpoint and the API endpoints used by ingaugo. Logging in still requires a local headless Chrome.
+//
+//	srv := ingtest.NewServer("12345678", "1234")
+//	defer srv.Close()
+//	srv.SetTransactions("0909090909", txns)
+//
+//	bank, _ := ingaugo.NewBank(ingaugo.WithBaseURL(srv.URL))
+//	session, err := bank.Login(ctx, "12345678", "1234")
+package ingtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/porjo/ingaugo"
+	"github.com/porjo/ingaugo/internal/keypad"
+)
+
+const (
+	loginPath              = "/securebanking/"
+	tokenPath              = "/api/token/login/issue"
+	exportTransactionsPath = "/api/ExportTransactions/Service/ExportTransactionsService.svc/json/ExportTransactions/ExportTransactions"
+	dashboardPath          = "/api/Dashboard/Service/DashboardService.svc/json/Dashboard/loaddashboard"
+
+	timeLayout = "2006-01-02T15:04:05-0700"
+)
+
+// Server is a fake ING server. Point a Bank at it with ingaugo.WithBaseURL(srv.URL)
+type Server struct {
+	*httptest.Server
+
+	clientNumber string
+	accessPin    string
+
+	mu            sync.Mutex
+	challengeCode string
+	tokens        map[string]bool
+	logins        int
+	transactions  map[string][]ingaugo.Transaction
+	accounts      []ingaugo.Account
+}
+
+// NewServer starts a fake ING server that accepts the given client number and access pin
+func NewServer(clientNumber, accessPin string) *Server {
+	srv := &Server{
+		clientNumber: clientNumber,
+		accessPin:    accessPin,
+		tokens:       make(map[string]bool),
+		transactions: make(map[string][]ingaugo.Transaction),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(loginPath, srv.handleLoginPage)
+	mux.HandleFunc(tokenPath, srv.handleToken)
+	mux.HandleFunc(exportTransactionsPath, srv.handleExportTransactions)
+	mux.HandleFunc(dashboardPath, srv.handleDashboard)
+	srv.Server = httptest.NewServer(mux)
+	return srv
+}
+
+// SetTransactions sets the transactions returned by ExportTransactions for accountNumber
+func (srv *Server) SetTransactions(accountNumber string, txns []ingaugo.Transaction) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	srv.transactions[accountNumber] = txns
+}
+
+// SetAccounts sets the accounts listed by the dashboard API
+func (srv *Server) SetAccounts(accounts []ingaugo.Account) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	srv.accounts = accounts
+}
+
+// SetChallengeCode makes logins present a step-up authentication challenge that is answered by code.
+// A wrong code clears the challenge input and asks for the code again. An empty code disables the challenge
+func (srv *Server) SetChallengeCode(code string) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	srv.challengeCode = code
+}
+
+// Logins returns the number of successful logins
+func (srv *Server) Logins() int {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return srv.logins
+}
+
+// ExpireTokens invalidates all issued tokens. API calls using them are rejected with 401 Unauthorized
+func (srv *Server) ExpireTokens() {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	srv.tokens = make(map[string]bool)
+}
+
+func (srv *Server) validToken(token string) bool {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return srv.tokens[token]
+}
+
+func (srv *Server) handleLoginPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	templates, err := keypad.Templates()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, loginPage(rand.Perm(keypad.Digits), templates))
+}
+
+type tokenRequest struct {
+	ClientNumber string
+	AccessPin    string
+	Code         string
+}
+
+type tokenResponse struct {
+	Token             string
+	ErrorMessage      string `json:",omitempty"`
+	ChallengeRequired bool   `json:",omitempty"`
+	ChallengeMessage  string `json:",omitempty"`
+}
+
+type dashboardResponse struct {
+	Response struct {
+		Categories []dashboardCategory
+	}
+}
+
+type dashboardCategory struct {
+	Name     string
+	Accounts []dashboardAccount
+}
+
+type dashboardAccount struct {
+	AccountNumber    string
+	BSB              string
+	ProductName      string
+	AccountName      string
+	AccountType      string
+	CurrentBalance   json.Number
+	AvailableBalance json.Number
+}
+
+func (srv *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req tokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	srv.mu.Lock()
+	challengeCode := srv.challengeCode
+	srv.mu.Unlock()
+
+	resp := tokenResponse{}
+	switch {
+	case req.ClientNumber != srv.clientNumber || req.AccessPin != srv.accessPin:
+		resp.ErrorMessage = "The login details you entered are incorrect"
+	case challengeCode != "" && req.Code == "":
+		resp.ChallengeRequired = true
+	case challengeCode != "" && req.Code != challengeCode:
+		resp.ChallengeRequired = true
+		resp.ChallengeMessage = "The verification code you entered is incorrect, enter the new code we sent to your mobile"
+	default:
+		srv.mu.Lock()
+		srv.logins++
+		resp.Token = fmt.Sprintf("token-%d-%d", srv.logins, time.Now().UnixNano())
+		srv.tokens[resp.Token] = true
+		srv.mu.Unlock()
+	}
+	writeJSON(w, resp)
+}
+
+func (srv *Server) handleExportTransactions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !srv.validToken(r.PostFormValue("X-AuthToken")) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	srv.mu.Lock()
+	txns, ok := srv.transactions[r.PostFormValue("AccountNumber")]
+	srv.mu.Unlock()
+	if !ok {
+		http.Error(w, "account not found", http.StatusNotFound)
+		return
+	}
+
+	from, err := time.Parse(timeLayout, r.PostFormValue("FilterStartDate"))
+	if err != nil {
+		http.Error(w, "invalid FilterStartDate", http.StatusBadRequest)
+		return
+	}
+	to, err := time.Parse(timeLayout, r.PostFormValue("FilterEndDate"))
+	if err != nil {
+		http.Error(w, "invalid FilterEndDate", http.StatusBadRequest)
+		return
+	}
+	txns = filterTransactions(txns, from, to)
+
+	format := r.PostFormValue("Format")
+	body, err := Render(format, r.PostFormValue("AccountNumber"), txns)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(body)
+}
+
+func (srv *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !srv.validToken(r.PostFormValue("X-AuthToken")) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	srv.mu.Lock()
+	accounts := make([]dashboardAccount, 0, len(srv.accounts))
+	for _, a := range srv.accounts {
+		accounts = append(accounts, dashboardAccount{
+			AccountNumber:    a.Number,
+			BSB:              a.BSB,
+			ProductName:      a.ProductName,
+			AccountName:      a.Nickname,
+			AccountType:      a.Type,
+			CurrentBalance:   json.Number(a.CurrentBalance.String()),
+			AvailableBalance: json.Number(a.AvailableBalance.String()),
+		})
+	}
+	srv.mu.Unlock()
+
+	resp := dashboardResponse{}
+	resp.Response.Categories = append(resp.Response.Categories, dashboardCategory{Name: "Accounts", Accounts: accounts})
+	writeJSON(w, resp)
+}
+
+// filterTransactions returns the transactions dated on or after the day of from, and before to.
+// Transaction dates are calendar dates, so they are compared in the timezone of the filter
+func filterTransactions(txns []ingaugo.Transaction, from, to time.Time) []ingaugo.Transaction {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	filtered := make([]ingaugo.Transaction, 0, len(txns))
+	for _, t := range txns {
+		day := time.Date(t.Date.Year(), t.Date.Month(), t.Date.Day(), 0, 0, 0, 0, from.Location())
+		if day.Before(from) || !day.Before(to) {
+			continue
+		}
+		filtered = append(filtered, t)
+	}
+	return filtered
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,63 @@
+// Package keypad holds reference images of the digits on the ING login keypad.
+//
+// The images live in templates/<digit>.png and are embedded at build time. Regenerate them with cmd/keypad-capture
+// when ING restyles the keypad
+package keypad
+
+import (
+	"bytes"
+	"embed"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+)
+
+// Digits is the number of keys on the keypad
+const Digits = 10
+
+//go:embed templates/*.png
+var templates embed.FS
+
+// PNG returns the PNG encoded image of each keypad digit, indexed by digit
+func PNG() ([][]byte, error) {
+	pngs := make([][]byte, 0, Digits)
+	for d := 0; d < Digits; d++ {
+		b, err := templates.ReadFile(fmt.Sprintf("templates/%d.png", d))
+		if err != nil {
+			return nil, err
+		}
+		pngs = append(pngs, b)
+	}
+	return pngs, nil
+}
+
+// Templates returns the base64 encoded PNG image of each keypad digit, indexed by digit
+func Templates() ([]string, error) {
+	pngs, err := PNG()
+	if err != nil {
+		return nil, err
+	}
+	t := make([]string, 0, len(pngs))
+	for _, b := range pngs {
+		t = append(t, base64.StdEncoding.EncodeToString(b))
+	}
+	return t, nil
+}
+
+// Images returns the decoded image of each keypad digit, indexed by digit
+func Images() ([]image.Image, error) {
+	pngs, err := PNG()
+	if err != nil {
+		return nil, err
+	}
+	images := make([]image.Image, 0, len(pngs))
+	for _, b := range pngs {
+		i, err := png.Decode(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, i)
+	}
+	return images, nil
+}
@@ -0,0 +1,202 @@
+package ingaugo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultCurrency       = "AUD"
+	defaultAccountPrefix  = "Assets:ING:"
+	defaultExpenseAccount = "Expenses:Unknown"
+	defaultIncomeAccount  = "Income:Unknown"
+)
+
+// WriteOptions configures WriteTransactions. Zero values select the defaults
+type WriteOptions struct {
+	// Accounts maps ING account numbers to journal account names e.g. "Assets:ING:Savings".
+	// Unmapped accounts are named Assets:ING:<account number>
+	Accounts map[string]string
+	// ExpenseAccount and IncomeAccount are the other side of debits and credits. Default to Expenses:Unknown and Income:Unknown
+	ExpenseAccount string
+	IncomeAccount  string
+	// Currency defaults to AUD
+	Currency string
+	// BalanceAssertions asserts the running balance of the account where the export includes it (CSV)
+	BalanceAssertions bool
+	// OpenAccounts starts a beancount journal with an open directive for each account it uses, dated on the
+	// first transaction, so that the journal can be checked on its own. Leave it unset when the journal is
+	// included in a main journal that opens the accounts
+	OpenAccounts bool
+}
+
+func (opts WriteOptions) account(accountNumber string) string {
+	if name, ok := opts.Accounts[accountNumber]; ok {
+		return name
+	}
+	if accountNumber == "" {
+		return strings.TrimSuffix(defaultAccountPrefix, ":")
+	}
+	return defaultAccountPrefix + accountNumber
+}
+
+func (opts WriteOptions) contraAccount(amount Amount) string {
+	if amount < 0 {
+		if opts.ExpenseAccount != "" {
+			return opts.ExpenseAccount
+		}
+		return defaultExpenseAccount
+	}
+	if opts.IncomeAccount != "" {
+		return opts.IncomeAccount
+	}
+	return defaultIncomeAccount
+}
+
+func (opts WriteOptions) currency() string {
+	if opts.Currency != "" {
+		return opts.Currency
+	}
+	return defaultCurrency
+}
+
+// WriteTransactions writes transactions in a client-side format (csv, beancount, ledger, json or ndjson).
+// Journal formats are written oldest first
+func WriteTransactions(w io.Writer, format Format, txns []Transaction, opts WriteOptions) error {
+	switch format {
+	case CSV:
+		return WriteCSV(w, txns)
+	case Beancount:
+		return WriteBeancount(w, txns, opts)
+	case Ledger:
+		return WriteLedger(w, txns, opts)
+	case JSON:
+		return WriteJSON(w, txns)
+	case NDJSON:
+		return WriteNDJSON(w, txns)
+	}
+	return fmt.Errorf("unsupported format %q", format)
+}
+
+// journalOrder returns a copy of txns oldest first. Each account is put in order separately, since the exports
+// of several accounts may be concatenated, and the transactions of an account on the same date are kept together
+func journalOrder(txns []Transaction) []Transaction {
+	var accounts []string
+	byAccount := make(map[string][]Transaction)
+	for _, t := range txns {
+		if _, ok := byAccount[t.Account]; !ok {
+			accounts = append(accounts, t.Account)
+		}
+		byAccount[t.Account] = append(byAccount[t.Account], t)
+	}
+	ordered := make([]Transaction, 0, len(txns))
+	for _, account := range accounts {
+		chronological(byAccount[account])
+		ordered = append(ordered, byAccount[account]...)
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Date.Before(ordered[j].Date)
+	})
+	return ordered
+}
+
+var payeeReceiptRe = regexp.MustCompile(`(?i)(^|\s+)(receipt|ref|reference)\b.*$`)
+
+// Payee extracts the merchant or counterparty from an ING description, which is typically of the form
+// "<type> - <payee> - Receipt <number> ..." e.g. "Visa Purchase - COFFEE CO SYDNEY AU - Receipt 123456" gives
+// "COFFEE CO SYDNEY AU". Descriptions without a payee part are returned as is
+func Payee(description string) string {
+	parts := strings.Split(description, " - ")
+	if len(parts) >= 2 {
+		payee := strings.TrimSpace(payeeReceiptRe.ReplaceAllString(parts[1], ""))
+		if payee != "" {
+			return payee
+		}
+	}
+	return strings.TrimSpace(description)
+}
+
+// WriteBeancount writes transactions as a beancount journal. Each transaction has an id metadata field holding
+// its ID. With balance assertions, a balance directive follows each day with the running balance at its end,
+// dated the next day as beancount checks balances at the start of the day
+func WriteBeancount(w io.Writer, txns []Transaction, opts WriteOptions) error {
+	txns = journalOrder(txns)
+
+	bw := bufio.NewWriter(w)
+	currency := opts.currency()
+	if opts.OpenAccounts && len(txns) > 0 {
+		writeBeancountOpen(bw, txns, opts)
+	}
+	for i, t := range txns {
+		account := opts.account(t.Account)
+		fmt.Fprintf(bw, "%s * %s %s\n", t.Date.Format("2006-01-02"), beancountString(Payee(t.Description)), beancountString(t.Description))
+		if t.ID != "" {
+			fmt.Fprintf(bw, "  id: %s\n", beancountString(t.ID))
+		}
+		fmt.Fprintf(bw, "  %s  %s %s\n", account, t.Amount, currency)
+		fmt.Fprintf(bw, "  %s\n", opts.contraAccount(t.Amount))
+		fmt.Fprintln(bw)
+
+		// journalOrder keeps the transactions of an account on the same date together
+		endOfDay := i == len(txns)-1 || !txns[i+1].Date.Equal(t.Date) || txns[i+1].Account != t.Account
+		if opts.BalanceAssertions && t.Balance != nil && endOfDay {
+			fmt.Fprintf(bw, "%s balance %s  %s %s\n\n", t.Date.AddDate(0, 0, 1).Format("2006-01-02"), account, *t.Balance, currency)
+		}
+	}
+	return bw.Flush()
+}
+
+// writeBeancountOpen opens the accounts used by txns, which are oldest first, on the date of the first transaction
+func writeBeancountOpen(w io.Writer, txns []Transaction, opts WriteOptions) {
+	used := make(map[string]bool)
+	for _, t := range txns {
+		used[opts.account(t.Account)] = true
+		used[opts.contraAccount(t.Amount)] = true
+	}
+	accounts := make([]string, 0, len(used))
+	for account := range used {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+
+	date := txns[0].Date.Format("2006-01-02")
+	for _, account := range accounts {
+		fmt.Fprintf(w, "%s open %s %s\n", date, account, opts.currency())
+	}
+	fmt.Fprintln(w)
+}
+
+func beancountString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// WriteLedger writes transactions as a ledger journal, which hledger reads too. The transaction ID is kept in an
+// id tag. With balance assertions, the posting to the ING account asserts the running balance after it
+func WriteLedger(w io.Writer, txns []Transaction, opts WriteOptions) error {
+	txns = journalOrder(txns)
+
+	bw := bufio.NewWriter(w)
+	currency := opts.currency()
+	for _, t := range txns {
+		// ';' starts a comment, so keep it out of the payee
+		payee := strings.ReplaceAll(Payee(t.Description), ";", ",")
+		fmt.Fprintf(bw, "%s %s\n", t.Date.Format("2006-01-02"), payee)
+		fmt.Fprintf(bw, "    ; %s\n", t.Description)
+		if t.ID != "" {
+			fmt.Fprintf(bw, "    ; id: %s\n", t.ID)
+		}
+		assertion := ""
+		if opts.BalanceAssertions && t.Balance != nil {
+			assertion = fmt.Sprintf(" = %s %s", *t.Balance, currency)
+		}
+		fmt.Fprintf(bw, "    %s  %s %s%s\n", opts.account(t.Account), t.Amount, currency, assertion)
+		fmt.Fprintf(bw, "    %s\n", opts.contraAccount(t.Amount))
+		fmt.Fprintln(bw)
+	}
+	return bw.Flush()
+}
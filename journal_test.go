@@ -0,0 +1,174 @@
+package ingaugo_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/porjo/ingaugo"
+)
+
+func TestPayee(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{"Visa Purchase - COFFEE CO SYDNEY AU - Receipt 123456", "COFFEE CO SYDNEY AU"},
+		{"EFTPOS Purchase - WOOLWORTHS 1234 Receipt 654321 In SYDNEY", "WOOLWORTHS 1234"},
+		{"Transfer - JANE CITIZEN Ref rent", "JANE CITIZEN"},
+		{"Internet Transfer - Reference 123", "Internet Transfer - Reference 123"},
+		{"Salary Deposit", "Salary Deposit"},
+		{"  Interest Credit  ", "Interest Credit"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := ingaugo.Payee(tt.description); got != tt.want {
+			t.Errorf("Payee(%q) = %q, want %q", tt.description, got, tt.want)
+		}
+	}
+}
+
+// journalTransactions returns the exports of accounts A and B concatenated, each newest first as ING exports them.
+// Both accounts have transactions on 2 March
+func journalTransactions() []ingaugo.Transaction {
+	return []ingaugo.Transaction{
+		{ID: "a3", Account: "A", Date: date(2024, 3, 2), Amount: -450, Description: `Visa Purchase - "CAFE" - Receipt 1`, Balance: amountPtr(9100)},
+		{ID: "a2", Account: "A", Date: date(2024, 3, 2), Amount: -450, Description: "Visa Purchase - CAFE - Receipt 2", Balance: amountPtr(9550)},
+		{ID: "a1", Account: "A", Date: date(2024, 3, 1), Amount: 10000, Description: "Salary Deposit", Balance: amountPtr(10000)},
+		{ID: "b1", Account: "B", Date: date(2024, 3, 2), Amount: 2000, Description: "Transfer; savings", Balance: amountPtr(52000)},
+	}
+}
+
+func TestWriteBeancount(t *testing.T) {
+	var buf bytes.Buffer
+	opts := ingaugo.WriteOptions{Accounts: map[string]string{"A": "Assets:ING:Everyday"}, BalanceAssertions: true}
+	if err := ingaugo.WriteBeancount(&buf, journalTransactions(), opts); err != nil {
+		t.Fatal(err)
+	}
+	want := `2024-03-01 * "Salary Deposit" "Salary Deposit"
+  id: "a1"
+  Assets:ING:Everyday  100.00 AUD
+  Income:Unknown
+
+2024-03-02 balance Assets:ING:Everyday  100.00 AUD
+
+2024-03-02 * "CAFE" "Visa Purchase - CAFE - Receipt 2"
+  id: "a2"
+  Assets:ING:Everyday  -4.50 AUD
+  Expenses:Unknown
+
+2024-03-02 * "\"CAFE\"" "Visa Purchase - \"CAFE\" - Receipt 1"
+  id: "a3"
+  Assets:ING:Everyday  -4.50 AUD
+  Expenses:Unknown
+
+2024-03-03 balance Assets:ING:Everyday  91.00 AUD
+
+2024-03-02 * "Transfer; savings" "Transfer; savings"
+  id: "b1"
+  Assets:ING:B  20.00 AUD
+  Income:Unknown
+
+2024-03-03 balance Assets:ING:B  520.00 AUD
+
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteBeancount =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteBeancountOpenAccounts(t *testing.T) {
+	var buf bytes.Buffer
+	opts := ingaugo.WriteOptions{Accounts: map[string]string{"A": "Assets:ING:Everyday"}, OpenAccounts: true}
+	if err := ingaugo.WriteBeancount(&buf, journalTransactions(), opts); err != nil {
+		t.Fatal(err)
+	}
+	wantOpen := `2024-03-01 open Assets:ING:B AUD
+2024-03-01 open Assets:ING:Everyday AUD
+2024-03-01 open Expenses:Unknown AUD
+2024-03-01 open Income:Unknown AUD
+
+2024-03-01 * "Salary Deposit"`
+	if got := buf.String(); !strings.HasPrefix(got, wantOpen) {
+		t.Errorf("WriteBeancount =\n%s\nwant it to start with\n%s", got, wantOpen)
+	}
+
+	buf.Reset()
+	if err := ingaugo.WriteBeancount(&buf, nil, opts); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("WriteBeancount without transactions = %q, want nothing", buf.String())
+	}
+}
+
+func TestWriteBeancountOneAssertionPerAccountAndDay(t *testing.T) {
+	// the accounts interleave on the same day
+	txns := []ingaugo.Transaction{
+		{Account: "A", Date: date(2024, 3, 2), Amount: -100, Description: "a1", Balance: amountPtr(900)},
+		{Account: "B", Date: date(2024, 3, 2), Amount: -100, Description: "b1", Balance: amountPtr(400)},
+		{Account: "A", Date: date(2024, 3, 2), Amount: -100, Description: "a2", Balance: amountPtr(800)},
+	}
+	var buf bytes.Buffer
+	if err := ingaugo.WriteBeancount(&buf, txns, ingaugo.WriteOptions{BalanceAssertions: true}); err != nil {
+		t.Fatal(err)
+	}
+	var balances []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.Contains(line, " balance ") {
+			balances = append(balances, line)
+		}
+	}
+	want := []string{"2024-03-03 balance Assets:ING:A  8.00 AUD", "2024-03-03 balance Assets:ING:B  4.00 AUD"}
+	if strings.Join(balances, "\n") != strings.Join(want, "\n") {
+		t.Errorf("balance directives =\n%s\nwant\n%s", strings.Join(balances, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestWriteLedger(t *testing.T) {
+	var buf bytes.Buffer
+	opts := ingaugo.WriteOptions{
+		Accounts:          map[string]string{"A": "Assets:ING:Everyday"},
+		ExpenseAccount:    "Expenses:Misc",
+		IncomeAccount:     "Income:Misc",
+		Currency:          "$",
+		BalanceAssertions: true,
+	}
+	if err := ingaugo.WriteLedger(&buf, journalTransactions(), opts); err != nil {
+		t.Fatal(err)
+	}
+	want := `2024-03-01 Salary Deposit
+    ; Salary Deposit
+    ; id: a1
+    Assets:ING:Everyday  100.00 $ = 100.00 $
+    Income:Misc
+
+2024-03-02 CAFE
+    ; Visa Purchase - CAFE - Receipt 2
+    ; id: a2
+    Assets:ING:Everyday  -4.50 $ = 95.50 $
+    Expenses:Misc
+
+2024-03-02 "CAFE"
+    ; Visa Purchase - "CAFE" - Receipt 1
+    ; id: a3
+    Assets:ING:Everyday  -4.50 $ = 91.00 $
+    Expenses:Misc
+
+2024-03-02 Transfer, savings
+    ; Transfer; savings
+    ; id: b1
+    Assets:ING:B  20.00 $ = 520.00 $
+    Income:Misc
+
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteLedger =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteTransactionsUnsupported(t *testing.T) {
+	if err := ingaugo.WriteTransactions(&bytes.Buffer{}, ingaugo.OFX, nil, ingaugo.WriteOptions{}); err == nil {
+		t.Error("WriteTransactions(ofx) succeeded")
+	}
+}
@@ -0,0 +1,54 @@
+package ingaugo
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// jsonTransaction is the normalised form of a Transaction written by WriteJSON and WriteNDJSON
+type jsonTransaction struct {
+	ID            string  `json:"id"`
+	AccountNumber string  `json:"accountNumber"`
+	Date          string  `json:"date"`
+	Amount        string  `json:"amount"`
+	Description   string  `json:"description"`
+	Balance       *string `json:"balance"`
+}
+
+func newJSONTransaction(t Transaction) jsonTransaction {
+	jt := jsonTransaction{
+		ID:            t.ID,
+		AccountNumber: t.Account,
+		Date:          t.Date.Format("2006-01-02"),
+		Amount:        t.Amount.String(),
+		Description:   t.Description,
+	}
+	if t.Balance != nil {
+		balance := t.Balance.String()
+		jt.Balance = &balance
+	}
+	return jt
+}
+
+// WriteJSON writes transactions as a JSON array. Dates are YYYY-MM-DD, amounts are decimal strings e.g. "-12.34"
+// and balance is null where the export has no running balance
+func WriteJSON(w io.Writer, txns []Transaction) error {
+	out := make([]jsonTransaction, 0, len(txns))
+	for _, t := range txns {
+		out = append(out, newJSONTransaction(t))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// WriteNDJSON writes transactions as newline delimited JSON, one object per line in the form written by WriteJSON
+func WriteNDJSON(w io.Writer, txns []Transaction) error {
+	enc := json.NewEncoder(w)
+	for _, t := range txns {
+		if err := enc.Encode(newJSONTransaction(t)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
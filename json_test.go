@@ -0,0 +1,107 @@
+package ingaugo_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/porjo/ingaugo"
+)
+
+func TestWriteJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		txn  ingaugo.Transaction
+		want map[string]interface{}
+	}{
+		{
+			name: "debit with balance",
+			txn:  ingaugo.Transaction{ID: "6e38182d798252b5", Account: "0909090909", Date: date(2024, 3, 2), Amount: -450, Description: "Coffee", Balance: amountPtr(1000)},
+			want: map[string]interface{}{"id": "6e38182d798252b5", "accountNumber": "0909090909", "date": "2024-03-02", "amount": "-4.50", "description": "Coffee", "balance": "10.00"},
+		},
+		{
+			name: "credit without balance",
+			txn:  ingaugo.Transaction{ID: "a1", Account: "0909090909", Date: date(2024, 12, 31), Amount: 123456789, Description: "Salary"},
+			want: map[string]interface{}{"id": "a1", "accountNumber": "0909090909", "date": "2024-12-31", "amount": "1234567.89", "description": "Salary", "balance": nil},
+		},
+		{
+			name: "zero amount and balance",
+			txn:  ingaugo.Transaction{Date: date(2024, 1, 1), Description: `"quoted" & <escaped>`, Balance: amountPtr(0)},
+			want: map[string]interface{}{"id": "", "accountNumber": "", "date": "2024-01-01", "amount": "0.00", "description": `"quoted" & <escaped>`, "balance": "0.00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := ingaugo.WriteJSON(&buf, []ingaugo.Transaction{tt.txn}); err != nil {
+				t.Fatal(err)
+			}
+			var arr []map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &arr); err != nil {
+				t.Fatalf("WriteJSON output %q: %v", buf.String(), err)
+			}
+			if len(arr) != 1 || !reflect.DeepEqual(arr[0], tt.want) {
+				t.Errorf("WriteJSON = %v, want [%v]", arr, tt.want)
+			}
+
+			buf.Reset()
+			if err := ingaugo.WriteNDJSON(&buf, []ingaugo.Transaction{tt.txn}); err != nil {
+				t.Fatal(err)
+			}
+			var obj map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &obj); err != nil {
+				t.Fatalf("WriteNDJSON output %q: %v", buf.String(), err)
+			}
+			if !reflect.DeepEqual(obj, tt.want) {
+				t.Errorf("WriteNDJSON = %v, want %v", obj, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteJSONEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ingaugo.WriteJSON(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "[]\n" {
+		t.Errorf("WriteJSON(nil) = %q, want %q", got, "[]\n")
+	}
+
+	buf.Reset()
+	if err := ingaugo.WriteNDJSON(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("WriteNDJSON(nil) = %q, want nothing", buf.String())
+	}
+}
+
+func TestWriteNDJSONLines(t *testing.T) {
+	// a line break in a description is escaped, so each transaction stays on one line
+	txns := append([]ingaugo.Transaction{{Date: date(2024, 3, 1), Amount: -100, Description: "two\nlines"}}, fixtureTransactions...)
+	var buf bytes.Buffer
+	if err := ingaugo.WriteNDJSON(&buf, txns); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.HasSuffix(out, "\n") {
+		t.Errorf("WriteNDJSON output doesn't end with a newline: %q", out)
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != len(txns) {
+		t.Fatalf("WriteNDJSON wrote %d lines, want %d", len(lines), len(txns))
+	}
+	for i, line := range lines {
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &obj); err != nil {
+			t.Fatalf("line %d %q: %v", i+1, line, err)
+		}
+		if obj["description"] != txns[i].Description || obj["amount"] != txns[i].Amount.String() {
+			t.Errorf("line %d = %v, want transaction %d", i+1, obj, i)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package ingaugo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"strconv"
+
+	"github.com/chromedp/cdproto/cdp"
+	dp "github.com/chromedp/chromedp"
+	images "github.com/vitali-fedulov/images4"
+
+	"github.com/porjo/ingaugo/internal/keypad"
+)
+
+const (
+	// iconMaxDistance is the largest icon distance between a key and its reference image that is still considered a match
+	iconMaxDistance = 20.0
+	// keypadMinConfidence is the lowest confidence accepted before the keypad is considered ambiguous
+	keypadMinConfidence = 0.5
+)
+
+// KeypadError is returned when the login keypad can't be matched to digits unambiguously.
+// errors.Is(err, ErrKeypadNotRecognized) reports true for it
+type KeypadError struct {
+	Reason string
+	// Positions are the keypad positions of the offending keys
+	Positions []int
+	// Images are the PNG images of the offending keys, in the same order as Positions
+	Images     [][]byte
+	Confidence float64
+}
+
+func (e *KeypadError) Error() string {
+	return fmt.Sprintf("%s: %s for keys at positions %v (confidence %.2f)", ErrKeypadNotRecognized, e.Reason, e.Positions, e.Confidence)
+}
+
+func (e *KeypadError) Is(target error) bool {
+	return target == ErrKeypadNotRecognized
+}
+
+func (bank *Bank) generatePinClicks(ctx context.Context, accessPin string, imgNodes []*cdp.Node) (dp.Tasks, error) {
+	if len(imgNodes) == 0 {
+		return nil, fmt.Errorf("generatePinclicks, imgNodes is empty")
+	}
+	pngs := make([][]byte, 0, len(imgNodes))
+	keys := make([]image.Image, 0, len(imgNodes))
+	for i, node := range imgNodes {
+		src, ok := node.Attribute("src")
+		if !ok {
+			return nil, fmt.Errorf("generatePinclicks, keypad image %d has no src", i)
+		}
+		b, err := decodeDataURL(src)
+		if err != nil {
+			return nil, fmt.Errorf("generatePinclicks, keypad image %d: %w", i, err)
+		}
+		img, err := png.Decode(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("generatePinclicks, keypad image %d: %w", i, err)
+		}
+		pngs = append(pngs, b)
+		keys = append(keys, img)
+	}
+
+	keymap, confidence, err := bank.keypadRecognizer.Recognize(keys)
+	if err != nil {
+		var keypadErr *KeypadError
+		if errors.As(err, &keypadErr) {
+			for _, pos := range keypadErr.Positions {
+				keypadErr.Images = append(keypadErr.Images, pngs[pos])
+			}
+		}
+		// any failure of the recognizer means the keypad wasn't recognized, unlike the errors above
+		if !errors.Is(err, ErrKeypadNotRecognized) {
+			err = &kindError{kind: ErrKeypadNotRecognized, err: err}
+		}
+		return nil, err
+	}
+	bank.logger.Debug("Keypad recognized", "confidence", confidence)
+
+	clickTasks := make(dp.Tasks, 0)
+	for _, r := range accessPin {
+		digit, _ := strconv.Atoi(string(r))
+		clickIdx, ok := keymap[digit]
+		if ok {
+			clickTasks = append(clickTasks, dp.Click(".uia-pin-"+strconv.Itoa(clickIdx), dp.ByQuery))
+		}
+	}
+	if len(clickTasks) != len(accessPin) {
+		return nil, fmt.Errorf("generatePinclicks, clicktasks != pin length, %d != %d", len(clickTasks), len(accessPin))
+	}
+	return clickTasks, nil
+}
+
+// KeypadRecognizer matches the login keypad keys to digits
+type KeypadRecognizer interface {
+	// Recognize takes the image of each key in keypad order and returns a map of digit to keypad position,
+	// and the confidence of the match between 0 and 1
+	Recognize(keys []image.Image) (keymap map[int]int, confidence float64, err error)
+}
+
+// IconRecognizer compares keys with the reference images using images4 icons. It is the default KeypadRecognizer
+type IconRecognizer struct{}
+
+func (IconRecognizer) Recognize(keys []image.Image) (map[int]int, float64, error) {
+	templates, err := keypad.Images()
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(keys) != len(templates) {
+		return nil, 0, fmt.Errorf("%w: found %d keys, expected %d", ErrKeypadNotRecognized, len(keys), len(templates))
+	}
+
+	templateIcons := make([]images.IconT, len(templates))
+	for i, t := range templates {
+		templateIcons[i] = images.Icon(t)
+	}
+	cost := make([][]float64, len(keys))
+	for pos, key := range keys {
+		icon := images.Icon(key)
+		cost[pos] = make([]float64, len(templates))
+		for digit := range templates {
+			// This method is necessary as images.Similar() doesn't match correctly
+			m1, m2, m3 := images.EucMetric(icon, templateIcons[digit])
+			cost[pos][digit] = math.Max(m1, math.Max(m2, m3))
+		}
+	}
+	return matchKeypad(cost, iconMaxDistance)
+}
+
+// matchKeypad takes the distance between every key (row) and every digit (column) and returns a map of digit to
+// keypad position.
+//
+// Keys are assigned to digits one-to-one so that the total distance is minimal, which settles keys that are closest
+// to the same digit. The confidence of a key compares the assignment with the runner-up assignment that gives the key
+// a different digit: 1 minus the ratio of their distances over the keys that differ. It is 1 for a clear match and
+// 0 when both assignments are equally close. The returned confidence is the smallest over all keys.
+// Keys further than maxDistance from their digit are not considered a match
+func matchKeypad(cost [][]float64, maxDistance float64) (map[int]int, float64, error) {
+	assignment := assign(cost)
+
+	// blocked is a distance larger than any assignment, used to keep a key away from its assigned digit
+	blocked := 1.0
+	for _, row := range cost {
+		for _, c := range row {
+			blocked += c
+		}
+	}
+
+	keymap := make(map[int]int)
+	unmatched := make([]int, 0)
+	ambiguous := make([]int, 0)
+	confidence := 1.0
+	for pos, digit := range assignment {
+		keymap[digit] = pos
+
+		c := 1.0
+		if cost[pos][digit] >= maxDistance {
+			c = 0
+			unmatched = append(unmatched, pos)
+		} else if len(cost) > 1 {
+			c = runnerUpConfidence(cost, assignment, pos, blocked)
+		}
+		if c < keypadMinConfidence {
+			ambiguous = append(ambiguous, pos)
+		}
+		confidence = math.Min(confidence, c)
+	}
+
+	switch {
+	case len(unmatched) > 0:
+		return nil, confidence, &KeypadError{Reason: "no matching digit", Positions: unmatched, Confidence: confidence}
+	case len(ambiguous) > 0:
+		return nil, confidence, &KeypadError{Reason: "ambiguous match", Positions: ambiguous, Confidence: confidence}
+	}
+	return keymap, confidence, nil
+}
+
+// runnerUpConfidence returns the confidence of the key at pos: 1 minus the ratio of the distance of assignment to the
+// distance of the best assignment that gives the key another digit, counting only the keys whose digit differs
+func runnerUpConfidence(cost [][]float64, assignment []int, pos int, blocked float64) float64 {
+	alt := make([][]float64, len(cost))
+	for i, row := range cost {
+		alt[i] = append([]float64(nil), row...)
+	}
+	alt[pos][assignment[pos]] = blocked
+	runnerUp := assign(alt)
+
+	var best, next float64
+	for i := range assignment {
+		if runnerUp[i] != assignment[i] {
+			best += cost[i][assignment[i]]
+			next += cost[i][runnerUp[i]]
+		}
+	}
+	if next <= 0 {
+		return 0
+	}
+	return math.Max(0, 1-best/next)
+}
+
+// assign solves the assignment problem for the square cost matrix using the Hungarian algorithm.
+// It returns the column assigned to each row such that the total cost is minimal
+func assign(cost [][]float64) []int {
+	n := len(cost)
+	// potentials and matching use 1-based indexes, with 0 as a sentinel
+	u := make([]float64, n+1)
+	v := make([]float64, n+1)
+	match := make([]int, n+1) // match[col] = row
+	way := make([]int, n+1)
+
+	for row := 1; row <= n; row++ {
+		match[0] = row
+		col0 := 0
+		minv := make([]float64, n+1)
+		used := make([]bool, n+1)
+		for i := range minv {
+			minv[i] = math.Inf(1)
+		}
+		for {
+			used[col0] = true
+			row0 := match[col0]
+			delta := math.Inf(1)
+			col1 := 0
+			for col := 1; col <= n; col++ {
+				if used[col] {
+					continue
+				}
+				cur := cost[row0-1][col-1] - u[row0] - v[col]
+				if cur < minv[col] {
+					minv[col] = cur
+					way[col] = col0
+				}
+				if minv[col] < delta {
+					delta = minv[col]
+					col1 = col
+				}
+			}
+			for col := 0; col <= n; col++ {
+				if used[col] {
+					u[match[col]] += delta
+					v[col] -= delta
+				} else {
+					minv[col] -= delta
+				}
+			}
+			col0 = col1
+			if match[col0] == 0 {
+				break
+			}
+		}
+		for col0 != 0 {
+			col1 := way[col0]
+			match[col0] = match[col1]
+			col0 = col1
+		}
+	}
+
+	result := make([]int, n)
+	for col := 1; col <= n; col++ {
+		result[match[col]-1] = col - 1
+	}
+	return result
+}
@@ -0,0 +1,123 @@
+package ingaugo
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+
+	"github.com/porjo/ingaugo/internal/keypad"
+)
+
+const (
+	pixelGridWidth  = 16
+	pixelGridHeight = 24
+	// pixelForeground is the luminance difference from the background above which a pixel is part of the digit
+	pixelForeground = 64
+	// pixelMaxDistance is the largest percentage of differing grid cells that is still considered a match
+	pixelMaxDistance = 10.0
+)
+
+// PixelDiffRecognizer compares the digit shapes of keys and reference images pixel by pixel. Each image is reduced to
+// the digit's bounding box, sampled onto a fixed grid, and compared by the share of grid cells that differ.
+// Because only the shape of the digit is compared, it tolerates changes to colours, padding and image size
+type PixelDiffRecognizer struct{}
+
+func (PixelDiffRecognizer) Recognize(keys []image.Image) (map[int]int, float64, error) {
+	templates, err := keypad.Images()
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(keys) != len(templates) {
+		return nil, 0, fmt.Errorf("%w: found %d keys, expected %d", ErrKeypadNotRecognized, len(keys), len(templates))
+	}
+
+	templateGlyphs := make([][]float64, len(templates))
+	for i, t := range templates {
+		templateGlyphs[i] = glyph(t)
+	}
+	cost := make([][]float64, len(keys))
+	for pos, key := range keys {
+		g := glyph(key)
+		cost[pos] = make([]float64, len(templates))
+		for digit := range templates {
+			var diff float64
+			for i := range g {
+				diff += math.Abs(g[i] - templateGlyphs[digit][i])
+			}
+			cost[pos][digit] = diff / float64(len(g)) * 100
+		}
+	}
+	return matchKeypad(cost, pixelMaxDistance)
+}
+
+// glyph returns the share of foreground pixels in each cell of a grid laid over the digit's bounding box.
+// The background is the median luminance of the opaque pixels
+func glyph(img image.Image) []float64 {
+	b := img.Bounds()
+	lums := make([]float64, 0, b.Dx()*b.Dy())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if l, ok := luminance(img, x, y); ok {
+				lums = append(lums, l)
+			}
+		}
+	}
+	out := make([]float64, pixelGridWidth*pixelGridHeight)
+	if len(lums) == 0 {
+		return out
+	}
+	sort.Float64s(lums)
+	background := lums[len(lums)/2]
+	foreground := func(x, y int) bool {
+		l, ok := luminance(img, x, y)
+		return ok && math.Abs(l-background) > pixelForeground
+	}
+
+	box := image.Rectangle{}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if foreground(x, y) {
+				box = box.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	if box.Empty() {
+		return out
+	}
+
+	for gy := 0; gy < pixelGridHeight; gy++ {
+		y0 := box.Min.Y + gy*box.Dy()/pixelGridHeight
+		y1 := box.Min.Y + (gy+1)*box.Dy()/pixelGridHeight
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for gx := 0; gx < pixelGridWidth; gx++ {
+			x0 := box.Min.X + gx*box.Dx()/pixelGridWidth
+			x1 := box.Min.X + (gx+1)*box.Dx()/pixelGridWidth
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			n, fg := 0, 0
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					n++
+					if foreground(x, y) {
+						fg++
+					}
+				}
+			}
+			out[gy*pixelGridWidth+gx] = float64(fg) / float64(n)
+		}
+	}
+	return out
+}
+
+// luminance returns the luminance (0-255) of the pixel, and false if the pixel is mostly transparent
+func luminance(img image.Image, x, y int) (float64, bool) {
+	r, g, b, a := img.At(x, y).RGBA()
+	if a < 0x8000 {
+		return 0, false
+	}
+	return (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) * 255 / float64(a), true
+}
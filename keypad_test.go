@@ -0,0 +1,315 @@
+package ingaugo
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/chromedp/cdproto/cdp"
+	"golang.org/x/exp/slog"
+
+	"github.com/porjo/ingaugo/internal/keypad"
+)
+
+// bruteForceAssign returns the minimal total cost over all assignments of rows to columns
+func bruteForceAssign(cost [][]float64) float64 {
+	n := len(cost)
+	best := math.Inf(1)
+	cols := make([]int, n)
+	for i := range cols {
+		cols[i] = i
+	}
+	var permute func(k int, total float64)
+	permute = func(k int, total float64) {
+		if k == n {
+			best = math.Min(best, total)
+			return
+		}
+		for i := k; i < n; i++ {
+			cols[k], cols[i] = cols[i], cols[k]
+			permute(k+1, total+cost[k][cols[k]])
+			cols[k], cols[i] = cols[i], cols[k]
+		}
+	}
+	permute(0, 0)
+	return best
+}
+
+func TestAssign(t *testing.T) {
+	tests := []struct {
+		name string
+		cost [][]float64
+		want []int
+	}{
+		{
+			name: "identity",
+			cost: [][]float64{{0, 5, 5}, {5, 0, 5}, {5, 5, 0}},
+			want: []int{0, 1, 2},
+		},
+		{
+			name: "permuted",
+			cost: [][]float64{{5, 5, 0}, {0, 5, 5}, {5, 0, 5}},
+			want: []int{2, 0, 1},
+		},
+		{
+			// rows 0 and 1 are both closest to column 0, the total is smaller with row 1 taking column 2
+			name: "shared nearest column",
+			cost: [][]float64{{1, 9, 9}, {2, 9, 3}, {9, 1, 9}},
+			want: []int{0, 2, 1},
+		},
+		{
+			// greedy would give row 0 column 0 and leave row 1 with a cost of 100
+			name: "greedy is not optimal",
+			cost: [][]float64{{1, 2}, {2, 100}},
+			want: []int{1, 0},
+		},
+		{
+			name: "single",
+			cost: [][]float64{{7}},
+			want: []int{0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := assign(tt.cost); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("assign = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssignOptimal(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		n := 1 + rnd.Intn(7)
+		cost := make([][]float64, n)
+		for row := range cost {
+			cost[row] = make([]float64, n)
+			for col := range cost[row] {
+				cost[row][col] = float64(rnd.Intn(20))
+			}
+		}
+		got := assign(cost)
+		seen := make(map[int]bool)
+		var total float64
+		for row, col := range got {
+			if seen[col] {
+				t.Fatalf("assign(%v) = %v: column %d assigned twice", cost, got, col)
+			}
+			seen[col] = true
+			total += cost[row][col]
+		}
+		if want := bruteForceAssign(cost); total != want {
+			t.Fatalf("assign(%v) = %v with total %v, want total %v", cost, got, total, want)
+		}
+	}
+}
+
+// permutedCost returns a cost matrix where key pos shows digit perm[pos], with distance 1 to that digit
+// and far to every other digit
+func permutedCost(perm []int, far float64) [][]float64 {
+	cost := make([][]float64, len(perm))
+	for pos, digit := range perm {
+		cost[pos] = make([]float64, len(perm))
+		for d := range cost[pos] {
+			cost[pos][d] = far
+		}
+		cost[pos][digit] = 1
+	}
+	return cost
+}
+
+func TestMatchKeypad(t *testing.T) {
+	perm := []int{3, 7, 0, 9, 1, 4, 8, 2, 6, 5}
+	wantKeymap := make(map[int]int)
+	for pos, digit := range perm {
+		wantKeymap[digit] = pos
+	}
+
+	tests := []struct {
+		name          string
+		cost          func() [][]float64
+		wantKeymap    map[int]int
+		wantPositions []int
+		wantReason    string
+	}{
+		{
+			name:       "permuted",
+			cost:       func() [][]float64 { return permutedCost(perm, 15) },
+			wantKeymap: wantKeymap,
+		},
+		{
+			// key 1 (digit 7) looks closest to digit 3 of key 0, but only digit 7 is left for it
+			name: "duplicate nearest settled by assignment",
+			cost: func() [][]float64 {
+				cost := permutedCost(perm, 15)
+				cost[1][3] = 0.5
+				cost[1][7] = 2
+				return cost
+			},
+			wantKeymap: wantKeymap,
+		},
+		{
+			// keys 0 and 1 are equally close to digits 3 and 7, so swapping them is as good a match
+			name: "duplicate ambiguous",
+			cost: func() [][]float64 {
+				cost := permutedCost(perm, 15)
+				cost[0][7] = 1
+				cost[1][3] = 1
+				return cost
+			},
+			wantPositions: []int{0, 1},
+			wantReason:    "ambiguous match",
+		},
+		{
+			name: "close runner-up",
+			cost: func() [][]float64 {
+				cost := permutedCost(perm, 15)
+				cost[4][1] = 3
+				cost[4][4] = 4
+				cost[5][1] = 4
+				cost[5][4] = 3
+				return cost
+			},
+			wantPositions: []int{4, 5},
+			wantReason:    "ambiguous match",
+		},
+		{
+			name: "unmatched",
+			cost: func() [][]float64 {
+				cost := permutedCost(perm, 15)
+				cost[8][6] = 25
+				return cost
+			},
+			wantPositions: []int{8},
+			wantReason:    "no matching digit",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keymap, confidence, err := matchKeypad(tt.cost(), 20)
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(keymap, tt.wantKeymap) {
+					t.Errorf("keymap = %v, want %v", keymap, tt.wantKeymap)
+				}
+				if confidence < keypadMinConfidence || confidence > 1 {
+					t.Errorf("confidence = %.2f", confidence)
+				}
+				return
+			}
+
+			var keypadErr *KeypadError
+			if !errors.As(err, &keypadErr) {
+				t.Fatalf("error = %v, want *KeypadError", err)
+			}
+			if !errors.Is(err, ErrKeypadNotRecognized) {
+				t.Errorf("errors.Is(err, ErrKeypadNotRecognized) = false")
+			}
+			if keypadErr.Reason != tt.wantReason {
+				t.Errorf("Reason = %q, want %q", keypadErr.Reason, tt.wantReason)
+			}
+			if !reflect.DeepEqual(keypadErr.Positions, tt.wantPositions) {
+				t.Errorf("Positions = %v, want %v", keypadErr.Positions, tt.wantPositions)
+			}
+			if confidence >= keypadMinConfidence {
+				t.Errorf("confidence = %.2f, want below %.2f", confidence, keypadMinConfidence)
+			}
+		})
+	}
+}
+
+func TestRecognizers(t *testing.T) {
+	templates, err := keypad.Images()
+	if err != nil {
+		t.Fatal(err)
+	}
+	perm := rand.New(rand.NewSource(1)).Perm(len(templates))
+	keys := make([]image.Image, len(perm))
+	for pos, digit := range perm {
+		keys[pos] = templates[digit]
+	}
+
+	for name, recognizer := range map[string]KeypadRecognizer{"icon": IconRecognizer{}, "pixel": PixelDiffRecognizer{}} {
+		t.Run(name, func(t *testing.T) {
+			keymap, confidence, err := recognizer.Recognize(keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for pos, digit := range perm {
+				if keymap[digit] != pos {
+					t.Errorf("digit %d at position %d, want %d", digit, keymap[digit], pos)
+				}
+			}
+			if confidence < keypadMinConfidence {
+				t.Errorf("confidence = %.2f", confidence)
+			}
+
+			if _, _, err := recognizer.Recognize(keys[1:]); !errors.Is(err, ErrKeypadNotRecognized) {
+				t.Errorf("Recognize with a missing key: error = %v, want ErrKeypadNotRecognized", err)
+			}
+		})
+	}
+}
+
+type recognizerFunc func(keys []image.Image) (map[int]int, float64, error)
+
+func (f recognizerFunc) Recognize(keys []image.Image) (map[int]int, float64, error) {
+	return f(keys)
+}
+
+func TestGeneratePinClicksErrors(t *testing.T) {
+	templates, err := keypad.Images()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, templates[0]); err != nil {
+		t.Fatal(err)
+	}
+	key := &cdp.Node{Attributes: []string{"src", "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())}}
+
+	recognizerErr := errors.New("templates unavailable")
+	tests := []struct {
+		name       string
+		nodes      []*cdp.Node
+		err        error
+		wantKeypad bool
+	}{
+		{name: "recognizer error", nodes: []*cdp.Node{key}, err: recognizerErr, wantKeypad: true},
+		{name: "keypad error", nodes: []*cdp.Node{key}, err: &KeypadError{Reason: "ambiguous match", Positions: []int{0}}, wantKeypad: true},
+		{name: "no images", nodes: nil},
+		{name: "no src", nodes: []*cdp.Node{{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recognizer := recognizerFunc(func(keys []image.Image) (map[int]int, float64, error) {
+				return nil, 0, tt.err
+			})
+			bank, err := NewBank(WithKeypadRecognizer(recognizer), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = bank.generatePinClicks(context.Background(), "2580", tt.nodes)
+			if err == nil {
+				t.Fatal("generatePinClicks succeeded")
+			}
+			if errors.Is(err, ErrKeypadNotRecognized) != tt.wantKeypad {
+				t.Errorf("errors.Is(%v, ErrKeypadNotRecognized) = %t, want %t", err, !tt.wantKeypad, tt.wantKeypad)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("error = %v, want it to wrap %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package ingaugo_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/porjo/ingaugo"
+	"github.com/porjo/ingaugo/ingtest"
+	"golang.org/x/exp/slog"
+)
+
+const (
+	testClientNumber  = "12345678"
+	testAccessPin     = "2580"
+	testAccountNumber = "0909090909"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// newTestServer starts a fake ING server and returns it with a Bank pointed at it. Tests that need a browser are skipped
+// when no Chrome or Chromium binary is installed
+func newTestServer(t *testing.T, opts ...ingaugo.Option) (*ingtest.Server, *ingaugo.Bank) {
+	t.Helper()
+	if ingaugo.FindBrowser(true) == "" {
+		t.Skip("no Chrome or Chromium binary found")
+	}
+	srv := ingtest.NewServer(testClientNumber, testAccessPin)
+	t.Cleanup(srv.Close)
+
+	opts = append([]ingaugo.Option{
+		ingaugo.WithBaseURL(srv.URL),
+		ingaugo.WithLogger(discardLogger),
+	}, opts...)
+	bank, err := ingaugo.NewBank(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { bank.Close() })
+	return srv, bank
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestLogin(t *testing.T) {
+	srv, bank := newTestServer(t)
+	ctx := testContext(t)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	txns := []ingaugo.Transaction{
+		{Date: today.AddDate(0, 0, -2), Amount: 250000, Description: "Salary Deposit", Balance: amountPtr(250000)},
+		{Date: today.AddDate(0, 0, -1), Amount: -450, Description: "VISA Purchase Coffee", Balance: amountPtr(249550)},
+		// outside the requested window
+		{Date: today.AddDate(0, 0, -60), Amount: -100, Description: "Old", Balance: amountPtr(100)},
+	}
+	srv.SetTransactions(testAccountNumber, txns)
+	srv.SetAccounts([]ingaugo.Account{
+		{Number: testAccountNumber, BSB: "923100", ProductName: "Orange Everyday", Type: "Transaction", CurrentBalance: 249550, AvailableBalance: 249550},
+	})
+
+	session, err := bank.Login(ctx, testClientNumber, testAccessPin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Token() == "" {
+		t.Fatal("session has no token")
+	}
+	if srv.Logins() != 1 {
+		t.Errorf("server saw %d logins, want 1", srv.Logins())
+	}
+
+	got, err := session.Transactions(ctx, 30, ingaugo.CSV, testAccountNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d transactions, want 2", len(got))
+	}
+	for i, txn := range got {
+		if !txn.Date.Equal(txns[i].Date) || txn.Amount != txns[i].Amount || txn.Description != txns[i].Description {
+			t.Errorf("transaction %d = %s %s %q, want %s %s %q", i,
+				txn.Date.Format("2006-01-02"), txn.Amount, txn.Description,
+				txns[i].Date.Format("2006-01-02"), txns[i].Amount, txns[i].Description)
+		}
+	}
+
+	accounts, err := session.Accounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 1 || accounts[0].Number != testAccountNumber || accounts[0].CurrentBalance != 249550 {
+		t.Errorf("Accounts = %+v", accounts)
+	}
+}
+
+func TestLoginInvalidCredentials(t *testing.T) {
+	srv, bank := newTestServer(t)
+	ctx := testContext(t)
+
+	_, err := bank.Login(ctx, testClientNumber, "1111")
+	if !errors.Is(err, ingaugo.ErrInvalidCredentials) {
+		t.Fatalf("error = %v, want ErrInvalidCredentials", err)
+	}
+	if srv.Logins() != 0 {
+		t.Errorf("server saw %d logins, want 0", srv.Logins())
+	}
+}
+
+func TestLoginChallenge(t *testing.T) {
+	var challenges int32
+	handler := ingaugo.ChallengeHandlerFunc(func(ctx context.Context, challenge ingaugo.Challenge) (string, error) {
+		atomic.AddInt32(&challenges, 1)
+		if challenge.ClientNumber != testClientNumber {
+			t.Errorf("challenge ClientNumber = %q, want %q", challenge.ClientNumber, testClientNumber)
+		}
+		if challenge.Message == "" {
+			t.Error("challenge has no message")
+		}
+		return "424242", nil
+	})
+	srv, bank := newTestServer(t, ingaugo.WithChallengeHandler(handler))
+	srv.SetChallengeCode("424242")
+	ctx := testContext(t)
+
+	session, err := bank.Login(ctx, testClientNumber, testAccessPin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Token() == "" {
+		t.Fatal("session has no token")
+	}
+	if n := atomic.LoadInt32(&challenges); n != 1 {
+		t.Errorf("challenge handler called %d times, want 1", n)
+	}
+}
+
+func TestLoginChallengeMistyped(t *testing.T) {
+	var challenges int32
+	handler := ingaugo.ChallengeHandlerFunc(func(ctx context.Context, challenge ingaugo.Challenge) (string, error) {
+		if atomic.AddInt32(&challenges, 1) == 1 {
+			return "000000", nil
+		}
+		if !strings.Contains(challenge.Message, "incorrect") {
+			t.Errorf("second challenge message = %q, want the incorrect code message", challenge.Message)
+		}
+		return "424242", nil
+	})
+	srv, bank := newTestServer(t, ingaugo.WithChallengeHandler(handler))
+	srv.SetChallengeCode("424242")
+	ctx := testContext(t)
+
+	if _, err := bank.Login(ctx, testClientNumber, testAccessPin); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&challenges); n != 2 {
+		t.Errorf("challenge handler called %d times, want 2", n)
+	}
+	if srv.Logins() != 1 {
+		t.Errorf("server saw %d logins, want 1", srv.Logins())
+	}
+}
+
+func TestLoginChallengeWithoutHandler(t *testing.T) {
+	srv, bank := newTestServer(t)
+	srv.SetChallengeCode("424242")
+	ctx := testContext(t)
+
+	_, err := bank.Login(ctx, testClientNumber, testAccessPin)
+	if !errors.Is(err, ingaugo.ErrChallengeRequired) {
+		t.Fatalf("error = %v, want ErrChallengeRequired", err)
+	}
+}
+
+func TestSessionRelogin(t *testing.T) {
+	srv, bank := newTestServer(t)
+	ctx := testContext(t)
+	srv.SetAccounts([]ingaugo.Account{{Number: testAccountNumber}})
+
+	session, err := bank.Login(ctx, testClientNumber, testAccessPin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := session.Token()
+
+	srv.ExpireTokens()
+	accounts, err := session.Accounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 1 {
+		t.Errorf("got %d accounts, want 1", len(accounts))
+	}
+	if srv.Logins() != 2 {
+		t.Errorf("server saw %d logins, want 2", srv.Logins())
+	}
+	if session.Token() == first {
+		t.Error("session token was not replaced after the API rejected it")
+	}
+}
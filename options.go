@@ -0,0 +1,276 @@
+package ingaugo
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	dp "github.com/chromedp/chromedp"
+	"golang.org/x/exp/slog"
+)
+
+// Option configures a Bank. Options are passed to NewBank
+type Option func(*Bank) error
+
+// WithLogger sets the logger used by the Bank. Defaults to a text logger writing to stdout
+func WithLogger(logger *slog.Logger) Option {
+	return func(bank *Bank) error {
+		bank.logger = logger
+		return nil
+	}
+}
+
+// WithWebsocketURL connects to the browser instance listening at websocketURL instead of launching a local browser.
+// Besides a ws:// debugger URL, the browser's http://host:port address is accepted, in which case the debugger URL
+// is looked up from /json/version. The port defaults to 9222.
+// The lookup is always made over plain http, so wss:// and https:// URLs must be the full debugger URL
+// with its /devtools/browser/ path
+func WithWebsocketURL(websocketURL string) Option {
+	return func(bank *Bank) error {
+		if websocketURL == "" {
+			bank.wsURL = ""
+			return nil
+		}
+		u, err := url.Parse(websocketURL)
+		if err != nil {
+			return fmt.Errorf("invalid websocket URL %q: %w", websocketURL, err)
+		}
+		// chromedp looks up the debugger URL from /json/version for any URL without a /devtools/browser/ path
+		debuggerURL := strings.Contains(u.Path, "/devtools/browser/")
+		switch u.Scheme {
+		case "ws":
+		case "http":
+			if u.Port() == "" {
+				u.Host = net.JoinHostPort(u.Hostname(), defaultDebuggingPort)
+			}
+			u.Scheme = "ws"
+		case "wss", "https":
+			if !debuggerURL {
+				return fmt.Errorf("invalid websocket URL %q: %s requires the full /devtools/browser/ debugger URL, as the debugger URL is looked up over plain http", websocketURL, u.Scheme)
+			}
+			u.Scheme = "wss"
+		default:
+			return fmt.Errorf("invalid websocket URL %q: scheme must be ws, wss, http or https", websocketURL)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("invalid websocket URL %q: host is required", websocketURL)
+		}
+		bank.wsURL = u.String()
+		return nil
+	}
+}
+
+// WithBaseURL overrides the ING base URL (https://www.ing.com.au) that the login page
+// and API endpoints are resolved against e.g. to point at a local mock server
+func WithBaseURL(baseURL string) Option {
+	return func(bank *Bank) error {
+		u, err := url.Parse(baseURL)
+		if err != nil {
+			return fmt.Errorf("invalid base URL %q: %w", baseURL, err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid base URL %q: scheme and host are required", baseURL)
+		}
+		bank.baseURL = strings.TrimRight(baseURL, "/")
+		return nil
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for API calls
+func WithHTTPClient(client *http.Client) Option {
+	return func(bank *Bank) error {
+		bank.httpClient = client
+		return nil
+	}
+}
+
+// WithTransport sets the transport used for API calls e.g. to route requests through a proxy.
+// It takes precedence over the transport of a client supplied with WithHTTPClient
+func WithTransport(transport http.RoundTripper) Option {
+	return func(bank *Bank) error {
+		bank.transport = transport
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with API calls
+func WithUserAgent(userAgent string) Option {
+	return func(bank *Bank) error {
+		bank.userAgent = userAgent
+		return nil
+	}
+}
+
+// WithTimeout sets the timeout for each API call
+func WithTimeout(timeout time.Duration) Option {
+	return func(bank *Bank) error {
+		bank.timeout = timeout
+		return nil
+	}
+}
+
+// WithAllocatorOptions appends chromedp allocator options (e.g. dp.ProxyServer, dp.Flag) to the defaults
+// used when launching a local browser. They have no effect when a websocket URL is set
+func WithAllocatorOptions(opts ...dp.ExecAllocatorOption) Option {
+	return func(bank *Bank) error {
+		bank.allocOpts = append(bank.allocOpts, opts...)
+		return nil
+	}
+}
+
+// WithBrowserPath sets the Chrome or Chromium binary to launch. By default a headless-shell, Chromium or Chrome
+// binary is searched for in PATH and the usual install locations
+func WithBrowserPath(path string) Option {
+	return func(bank *Bank) error {
+		bank.browserPath = path
+		return nil
+	}
+}
+
+// WithHeadless sets whether the local browser runs headless (the default) or shows its window
+func WithHeadless(headless bool) Option {
+	return func(bank *Bank) error {
+		bank.headless = headless
+		return nil
+	}
+}
+
+// WithUserDataDir sets the profile directory of the local browser. Defaults to a new temporary directory for each login
+func WithUserDataDir(dir string) Option {
+	return func(bank *Bank) error {
+		bank.userDataDir = dir
+		return nil
+	}
+}
+
+// WithProxy routes the local browser and API calls through proxyURL e.g. http://proxy:3128.
+// A transport supplied with WithTransport takes precedence for API calls. The proxy is set on a copy of the
+// transport of a client supplied with WithHTTPClient, which must then be an *http.Transport
+func WithProxy(proxyURL string) Option {
+	return func(bank *Bank) error {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL %q: %w", proxyURL, err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q: scheme and host are required", proxyURL)
+		}
+		bank.proxy = u
+		return nil
+	}
+}
+
+// WithWindowSize sets the window size of the local browser
+func WithWindowSize(width, height int) Option {
+	return func(bank *Bank) error {
+		if width <= 0 || height <= 0 {
+			return fmt.Errorf("invalid window size %dx%d", width, height)
+		}
+		bank.windowWidth = width
+		bank.windowHeight = height
+		return nil
+	}
+}
+
+// WithBrowserFlag adds a command line flag for the local browser e.g. WithBrowserFlag("disable-gpu", true).
+// A value of false removes a default flag
+func WithBrowserFlag(name string, value interface{}) Option {
+	return func(bank *Bank) error {
+		bank.allocOpts = append(bank.allocOpts, dp.Flag(name, value))
+		return nil
+	}
+}
+
+// WithBrowserPool shares one browser between logins instead of starting a browser for each, running up to size
+// logins concurrently in isolated incognito browser contexts. Call Bank.Close to shut the browser down
+func WithBrowserPool(size int) Option {
+	return func(bank *Bank) error {
+		if size < 1 {
+			return fmt.Errorf("invalid browser pool size %d", size)
+		}
+		bank.pool = newBrowserPool(bank, size)
+		return nil
+	}
+}
+
+// WithTokenLifetime sets how long a Session considers its token valid before logging in again
+func WithTokenLifetime(lifetime time.Duration) Option {
+	return func(bank *Bank) error {
+		bank.tokenLifetime = lifetime
+		return nil
+	}
+}
+
+// WithTokenStore sets a TokenStore that Login consults before driving the browser, and that new tokens are saved to
+func WithTokenStore(store TokenStore) Option {
+	return func(bank *Bank) error {
+		bank.tokenStore = store
+		return nil
+	}
+}
+
+// WithKeypadRecognizer sets the strategy used to match the login keypad keys to digits. Defaults to IconRecognizer
+func WithKeypadRecognizer(recognizer KeypadRecognizer) Option {
+	return func(bank *Bank) error {
+		bank.keypadRecognizer = recognizer
+		return nil
+	}
+}
+
+// WithDiagnosticsDir enables writing a diagnostics bundle to a new directory under dir whenever a login fails.
+// The bundle holds a screenshot, the page HTML, the keypad images and the browser console and network logs.
+// It may include the client number, so keep dir private
+func WithDiagnosticsDir(dir string) Option {
+	return func(bank *Bank) error {
+		bank.diagnosticsDir = dir
+		return nil
+	}
+}
+
+// WithKeypadTimeout sets how long to wait for the login keypad to finish loading before falling back to polling
+// for the keypad images, which is also limited to timeout. The timeout must be positive
+func WithKeypadTimeout(timeout time.Duration) Option {
+	return func(bank *Bank) error {
+		if timeout <= 0 {
+			return fmt.Errorf("invalid keypad timeout %s", timeout)
+		}
+		bank.keypadTimeout = timeout
+		return nil
+	}
+}
+
+// WithLoginRetries retries a failed login up to retries times, waiting backoff before the first retry and
+// doubling it for each subsequent one. A rejected pin is never retried
+func WithLoginRetries(retries int, backoff time.Duration) Option {
+	return func(bank *Bank) error {
+		if retries < 0 {
+			return fmt.Errorf("invalid login retries %d", retries)
+		}
+		bank.loginRetries = retries
+		bank.loginBackoff = backoff
+		return nil
+	}
+}
+
+// WithChallengeHandler sets the handler that supplies verification codes for step-up authentication challenges.
+// Without one, a challenge fails the login with ErrChallengeRequired
+func WithChallengeHandler(handler ChallengeHandler) Option {
+	return func(bank *Bank) error {
+		bank.challengeHandler = handler
+		return nil
+	}
+}
+
+// WithRetryPolicy sets how failed API calls are retried. Defaults to DefaultRetryPolicy
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(bank *Bank) error {
+		if policy.MaxAttempts < 1 {
+			return fmt.Errorf("invalid retry policy: MaxAttempts must be at least 1")
+		}
+		bank.retryPolicy = policy
+		return nil
+	}
+}
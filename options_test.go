@@ -0,0 +1,103 @@
+package ingaugo
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestWithWebsocketURL(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "", want: ""},
+		{in: "ws://localhost:9222", want: "ws://localhost:9222"},
+		{in: "ws://localhost:9222/devtools/browser/abc", want: "ws://localhost:9222/devtools/browser/abc"},
+		{in: "http://localhost", want: "ws://localhost:9222"},
+		{in: "http://localhost:9333", want: "ws://localhost:9333"},
+		{in: "wss://chrome.example.com/devtools/browser/abc", want: "wss://chrome.example.com/devtools/browser/abc"},
+		{in: "https://chrome.example.com/devtools/browser/abc", want: "wss://chrome.example.com/devtools/browser/abc"},
+		// the debugger URL lookup would silently drop TLS
+		{in: "wss://chrome.example.com", wantErr: true},
+		{in: "https://chrome.example.com:9222", wantErr: true},
+		{in: "ftp://localhost", wantErr: true},
+		{in: "ws://", wantErr: true},
+		{in: "localhost:9222", wantErr: true},
+	}
+	for _, tt := range tests {
+		bank, err := NewBank(WithWebsocketURL(tt.in))
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("WithWebsocketURL(%q) = %q, want error", tt.in, bank.wsURL)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("WithWebsocketURL(%q): %v", tt.in, err)
+			continue
+		}
+		if bank.wsURL != tt.want {
+			t.Errorf("WithWebsocketURL(%q) = %q, want %q", tt.in, bank.wsURL, tt.want)
+		}
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestWithProxyHTTPClient(t *testing.T) {
+	proxyURL, _ := url.Parse("http://proxy:3128")
+
+	// the proxy is added to a copy of the client's transport, keeping its TLS config
+	tlsConfig := &tls.Config{ServerName: "ing.example.com"}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	bank, err := NewBank(WithHTTPClient(client), WithProxy(proxyURL.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport, ok := bank.httpClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("transport = %T, want *http.Transport", bank.httpClient.Transport)
+	}
+	if transport.TLSClientConfig == nil || transport.TLSClientConfig.ServerName != tlsConfig.ServerName {
+		t.Errorf("TLSClientConfig = %v, want the client's", transport.TLSClientConfig)
+	}
+	proxy, err := transport.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: "www.ing.com.au"}})
+	if err != nil || proxy.String() != proxyURL.String() {
+		t.Errorf("Proxy = %v, %v, want %s", proxy, err, proxyURL)
+	}
+	if client.Transport.(*http.Transport).Proxy != nil {
+		t.Error("caller's transport was modified")
+	}
+
+	// a transport the proxy can't be set on is refused rather than replaced
+	client = &http.Client{Transport: roundTripperFunc(http.DefaultTransport.RoundTrip)}
+	if _, err := NewBank(WithHTTPClient(client), WithProxy(proxyURL.String())); err == nil {
+		t.Error("NewBank with a custom RoundTripper and WithProxy succeeded")
+	}
+	if _, err := NewBank(WithHTTPClient(client), WithProxy(proxyURL.String()), WithTransport(http.DefaultTransport)); err != nil {
+		t.Errorf("NewBank with WithTransport: %v", err)
+	}
+}
+
+func TestWithKeypadTimeout(t *testing.T) {
+	for _, timeout := range []time.Duration{0, -time.Second} {
+		if _, err := NewBank(WithKeypadTimeout(timeout)); err == nil {
+			t.Errorf("WithKeypadTimeout(%s) succeeded", timeout)
+		}
+	}
+	bank, err := NewBank(WithKeypadTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bank.keypadTimeout != time.Second {
+		t.Errorf("keypadTimeout = %s, want 1s", bank.keypadTimeout)
+	}
+}
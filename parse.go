@@ -0,0 +1,326 @@
+package ingaugo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Transaction is a single account transaction parsed from an ING export
+type Transaction struct {
+	// ID is a stable fingerprint of the transaction, see AssignIDs
+	ID string
+	// Date is the date the transaction was posted, at midnight UTC
+	Date        time.Time
+	Amount      Amount
+	Description string
+	// Balance is the running balance after the transaction. It is nil when the export format does not include it (OFX, QIF)
+	Balance *Amount
+	Account string
+	// Raw holds the fields as they appeared in the export, keyed by CSV column, OFX tag or QIF field code
+	Raw map[string]string
+}
+
+// Amount is a monetary value in cents
+type Amount int64
+
+// String formats the amount as a decimal e.g. -12.34
+func (a Amount) String() string {
+	sign := ""
+	if a < 0 {
+		sign = "-"
+		a = -a
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, a/100, a%100)
+}
+
+// ParseAmount parses a decimal amount such as "-1,234.5" or "$12.34". The sign may come before or after
+// the dollar sign, so "-$1.00" and "$-1.00" are the same amount
+func ParseAmount(s string) (Amount, error) {
+	v := strings.TrimSpace(s)
+	neg := false
+	if strings.HasPrefix(v, "(") && strings.HasSuffix(v, ")") {
+		neg = true
+		v = v[1 : len(v)-1]
+	}
+	sign, v := cutSign(v)
+	if strings.HasPrefix(v, "$") {
+		v = v[1:]
+		if sign == "" {
+			sign, v = cutSign(v)
+		}
+	}
+	if sign == "-" {
+		neg = !neg
+	}
+	v = strings.ReplaceAll(v, ",", "")
+
+	whole, frac, _ := strings.Cut(v, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("invalid amount %q: more than 2 decimal places", s)
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+	if whole == "" {
+		whole = "0"
+	}
+	w, err := strconv.ParseUint(whole, 10, 63)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	f, err := strconv.ParseUint(frac, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	a := Amount(w*100 + f)
+	if neg {
+		a = -a
+	}
+	return a, nil
+}
+
+// cutSign splits a leading + or - from s
+func cutSign(s string) (sign, rest string) {
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		return s[:1], s[1:]
+	}
+	return "", s
+}
+
+// ParseTransactions parses an export in the given format
+func ParseTransactions(format Format, data []byte) ([]Transaction, error) {
+	switch format {
+	case CSV:
+		return ParseCSV(bytes.NewReader(data))
+	case OFX:
+		return ParseOFX(bytes.NewReader(data))
+	case QIF:
+		return ParseQIF(bytes.NewReader(data))
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+var dateLayouts = []string{"02/01/2006", "2/1/2006", "02/01/06", "2006-01-02"}
+
+func parseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
+
+// ParseCSV parses an ING CSV export. The first row must be a header containing
+// Date, Description and either Amount or Credit/Debit columns. A Balance column is optional
+func ParseCSV(r io.Reader) ([]Transaction, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cols := make(map[string]int)
+	for i, h := range header {
+		h = strings.TrimPrefix(h, "\ufeff")
+		header[i] = h
+		cols[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	if _, ok := cols["date"]; !ok {
+		return nil, fmt.Errorf("csv: missing Date column")
+	}
+	_, hasAmount := cols["amount"]
+	_, hasCredit := cols["credit"]
+	_, hasDebit := cols["debit"]
+	if !hasAmount && !hasCredit && !hasDebit {
+		return nil, fmt.Errorf("csv: missing Amount or Credit/Debit columns")
+	}
+
+	txns := make([]Transaction, 0)
+	line := 1
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line++
+		field := func(name string) string {
+			if i, ok := cols[name]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+
+		t := Transaction{
+			Description: field("description"),
+			Raw:         make(map[string]string),
+		}
+		for i, v := range rec {
+			if i < len(header) {
+				t.Raw[header[i]] = v
+			}
+		}
+		if t.Date, err = parseDate(field("date")); err != nil {
+			return nil, fmt.Errorf("csv line %d: %w", line, err)
+		}
+		if v := field("amount"); v != "" {
+			if t.Amount, err = ParseAmount(v); err != nil {
+				return nil, fmt.Errorf("csv line %d: %w", line, err)
+			}
+		}
+		if v := field("credit"); v != "" {
+			credit, err := ParseAmount(v)
+			if err != nil {
+				return nil, fmt.Errorf("csv line %d: %w", line, err)
+			}
+			t.Amount += credit
+		}
+		if v := field("debit"); v != "" {
+			debit, err := ParseAmount(v)
+			if err != nil {
+				return nil, fmt.Errorf("csv line %d: %w", line, err)
+			}
+			// debits are normally already negative, but be lenient
+			if debit > 0 {
+				debit = -debit
+			}
+			t.Amount += debit
+		}
+		if v := field("balance"); v != "" {
+			balance, err := ParseAmount(v)
+			if err != nil {
+				return nil, fmt.Errorf("csv line %d: %w", line, err)
+			}
+			t.Balance = &balance
+		}
+		txns = append(txns, t)
+	}
+	AssignIDs(txns)
+	return txns, nil
+}
+
+var (
+	ofxTransactionRe = regexp.MustCompile(`(?s)<STMTTRN>(.*?)</STMTTRN>`)
+	ofxTagRe         = regexp.MustCompile(`<([A-Z0-9.]+)>([^<\r\n]*)`)
+	ofxAccountRe     = regexp.MustCompile(`<ACCTID>([^<\r\n]*)`)
+)
+
+// ParseOFX parses an ING OFX export. Both SGML (OFX 1.x) and XML (OFX 2.x) variants are supported.
+// Character references such as &amp; are unescaped in tag values
+func ParseOFX(r io.Reader) ([]Transaction, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	account := ""
+	if m := ofxAccountRe.FindSubmatch(data); m != nil {
+		account = html.UnescapeString(strings.TrimSpace(string(m[1])))
+	}
+
+	txns := make([]Transaction, 0)
+	for i, block := range ofxTransactionRe.FindAllSubmatch(data, -1) {
+		t := Transaction{
+			Account: account,
+			Raw:     make(map[string]string),
+		}
+		for _, m := range ofxTagRe.FindAllSubmatch(block[1], -1) {
+			t.Raw[string(m[1])] = html.UnescapeString(strings.TrimSpace(string(m[2])))
+		}
+
+		posted := t.Raw["DTPOSTED"]
+		if len(posted) < 8 {
+			return nil, fmt.Errorf("ofx transaction %d: invalid DTPOSTED %q", i+1, posted)
+		}
+		if t.Date, err = time.Parse("20060102", posted[:8]); err != nil {
+			return nil, fmt.Errorf("ofx transaction %d: invalid DTPOSTED %q", i+1, posted)
+		}
+		if t.Amount, err = ParseAmount(t.Raw["TRNAMT"]); err != nil {
+			return nil, fmt.Errorf("ofx transaction %d: %w", i+1, err)
+		}
+		// NAME is limited to 32 characters, so prefer MEMO when present
+		t.Description = t.Raw["MEMO"]
+		if t.Description == "" {
+			t.Description = t.Raw["NAME"]
+		}
+		txns = append(txns, t)
+	}
+	AssignIDs(txns)
+	return txns, nil
+}
+
+// ParseQIF parses an ING QIF export
+func ParseQIF(r io.Reader) ([]Transaction, error) {
+	txns := make([]Transaction, 0)
+	scanner := bufio.NewScanner(r)
+
+	raw := make(map[string]string)
+	line := 0
+	record := func() error {
+		if len(raw) == 0 {
+			return nil
+		}
+		t := Transaction{Raw: raw}
+		raw = make(map[string]string)
+
+		var err error
+		if t.Date, err = parseDate(t.Raw["D"]); err != nil {
+			return fmt.Errorf("qif line %d: %w", line, err)
+		}
+		amount := t.Raw["T"]
+		if amount == "" {
+			amount = t.Raw["U"]
+		}
+		if t.Amount, err = ParseAmount(amount); err != nil {
+			return fmt.Errorf("qif line %d: %w", line, err)
+		}
+		t.Description = t.Raw["P"]
+		if t.Description == "" {
+			t.Description = t.Raw["M"]
+		}
+		txns = append(txns, t)
+		return nil
+	}
+
+	for scanner.Scan() {
+		line++
+		l := strings.TrimRight(scanner.Text(), "\r")
+		if l == "" || strings.HasPrefix(l, "!") {
+			continue
+		}
+		if l[0] == '^' {
+			if err := record(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		raw[l[:1]] = strings.TrimSpace(l[1:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// tolerate a missing final '^'
+	if err := record(); err != nil {
+		return nil, err
+	}
+	AssignIDs(txns)
+	return txns, nil
+}
@@ -0,0 +1,243 @@
+package ingaugo_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/porjo/ingaugo"
+	"github.com/porjo/ingaugo/ingtest"
+)
+
+func amountPtr(a ingaugo.Amount) *ingaugo.Amount {
+	return &a
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ingaugo.Amount
+	}{
+		{"0", 0},
+		{"12.34", 1234},
+		{"-12.34", -1234},
+		{"+12.34", 1234},
+		{"1,234.5", 123450},
+		{".5", 50},
+		{"7.", 700},
+		{"$12.34", 1234},
+		{"-$1.00", -100},
+		{"$-1.00", -100},
+		{"+$1.00", 100},
+		{"$+1.00", 100},
+		{"(12.34)", -1234},
+		{"($12.34)", -1234},
+		{" -0.01 ", -1},
+	}
+	for _, tt := range tests {
+		got, err := ingaugo.ParseAmount(tt.in)
+		if err != nil {
+			t.Errorf("ParseAmount(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAmount(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "$", "-", "abc", "1.234", "--1", "-$-1", "$$1", "1.2.3", "1-"} {
+		if got, err := ingaugo.ParseAmount(in); err == nil {
+			t.Errorf("ParseAmount(%q) = %d, want error", in, got)
+		}
+	}
+}
+
+func TestAmountString(t *testing.T) {
+	for _, a := range []ingaugo.Amount{0, 1, -1, 99, 100, -1234, 123456789} {
+		got, err := ingaugo.ParseAmount(a.String())
+		if err != nil {
+			t.Fatalf("ParseAmount(%q): %v", a.String(), err)
+		}
+		if got != a {
+			t.Errorf("ParseAmount(%d.String()) = %d", a, got)
+		}
+	}
+}
+
+var fixtureTransactions = []ingaugo.Transaction{
+	{Date: date(2024, 3, 1), Amount: 250000, Description: "Salary Deposit - Receipt 111111 ACME PTY LTD", Balance: amountPtr(260000)},
+	{Date: date(2024, 3, 2), Amount: -450, Description: "VISA Purchase - Receipt 222222 In SYDNEY Date 01 Mar 2024 Card 462263xxxxxx1234", Balance: amountPtr(259550)},
+	{Date: date(2024, 3, 2), Amount: -450, Description: "VISA Purchase - Receipt 222222 In SYDNEY Date 01 Mar 2024 Card 462263xxxxxx1234", Balance: amountPtr(259100)},
+	{Date: date(2024, 3, 15), Amount: -123456, Description: "Transfer to \"Savings\", rent & <bills>", Balance: amountPtr(135644)},
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	for _, format := range []ingaugo.Format{ingaugo.CSV, ingaugo.OFX, ingaugo.QIF} {
+		t.Run(format, func(t *testing.T) {
+			data, err := ingtest.Render(format, "0909090909", fixtureTransactions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			txns, err := ingaugo.ParseTransactions(format, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(txns) != len(fixtureTransactions) {
+				t.Fatalf("parsed %d transactions, want %d", len(txns), len(fixtureTransactions))
+			}
+			for i, got := range txns {
+				want := fixtureTransactions[i]
+				if !got.Date.Equal(want.Date) {
+					t.Errorf("transaction %d: Date = %s, want %s", i, got.Date, want.Date)
+				}
+				if got.Amount != want.Amount {
+					t.Errorf("transaction %d: Amount = %s, want %s", i, got.Amount, want.Amount)
+				}
+				if got.Description != want.Description {
+					t.Errorf("transaction %d: Description = %q, want %q", i, got.Description, want.Description)
+				}
+				if got.ID == "" {
+					t.Errorf("transaction %d: no ID", i)
+				}
+				switch format {
+				case ingaugo.CSV:
+					if got.Balance == nil || *got.Balance != *want.Balance {
+						t.Errorf("transaction %d: Balance = %v, want %s", i, got.Balance, want.Balance)
+					}
+				case ingaugo.OFX:
+					if got.Account != "0909090909" {
+						t.Errorf("transaction %d: Account = %q, want %q", i, got.Account, "0909090909")
+					}
+					fallthrough
+				default:
+					if got.Balance != nil {
+						t.Errorf("transaction %d: Balance = %s, want nil", i, got.Balance)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    []ingaugo.Amount
+		wantErr string
+	}{
+		{
+			name: "amount column",
+			csv:  "\ufeffDate,Description,Amount\n01/03/2024,Coffee,-4.50\n02/03/2024,Refund,$4.50\n",
+			want: []ingaugo.Amount{-450, 450},
+		},
+		{
+			name: "credit and debit columns",
+			csv:  "Date,Description,Credit,Debit,Balance\n01/03/2024,Pay,100.00,,100.00\n02/03/2024,Coffee,,4.50,95.50\n",
+			want: []ingaugo.Amount{10000, -450},
+		},
+		{
+			name: "debit column only",
+			csv:  "Date,Description,Debit\n01/03/2024,Coffee,-4.50\n",
+			want: []ingaugo.Amount{-450},
+		},
+		{
+			name: "header only",
+			csv:  "Date,Description,Amount\n",
+			want: []ingaugo.Amount{},
+		},
+		{
+			name: "empty",
+			csv:  "",
+		},
+		{
+			name:    "missing date column",
+			csv:     "Description,Amount\nCoffee,-4.50\n",
+			wantErr: "missing Date column",
+		},
+		{
+			name:    "missing amount columns",
+			csv:     "Date,Description,Balance\n01/03/2024,Coffee,95.50\n",
+			wantErr: "missing Amount or Credit/Debit columns",
+		},
+		{
+			name:    "invalid date",
+			csv:     "Date,Description,Amount\n2024/13/45,Coffee,-4.50\n",
+			wantErr: "csv line 2",
+		},
+		{
+			name:    "invalid amount",
+			csv:     "Date,Description,Amount\n01/03/2024,Coffee,four\n",
+			wantErr: "csv line 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txns, err := ingaugo.ParseCSV(strings.NewReader(tt.csv))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(txns) != len(tt.want) {
+				t.Fatalf("parsed %d transactions, want %d", len(txns), len(tt.want))
+			}
+			for i, txn := range txns {
+				if txn.Amount != tt.want[i] {
+					t.Errorf("transaction %d: Amount = %s, want %s", i, txn.Amount, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseOFXXML(t *testing.T) {
+	ofx := `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<BANKACCTFROM><BANKID>923100</BANKID><ACCTID>0909090909</ACCTID></BANKACCTFROM>
+<BANKTRANLIST>
+<STMTTRN><TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20240302120000</DTPOSTED><TRNAMT>-12.50</TRNAMT><FITID>1</FITID>
+<NAME>Fish &amp; Chips</NAME><MEMO>Fish &amp; Chips &lt;Bondi&gt; Joe&#39;s</MEMO></STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>
+`
+	txns, err := ingaugo.ParseOFX(strings.NewReader(ofx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) != 1 {
+		t.Fatalf("parsed %d transactions, want 1", len(txns))
+	}
+	txn := txns[0]
+	if want := "Fish & Chips <Bondi> Joe's"; txn.Description != want || txn.Raw["MEMO"] != want {
+		t.Errorf("Description = %q, Raw MEMO = %q, want %q", txn.Description, txn.Raw["MEMO"], want)
+	}
+	if txn.Raw["NAME"] != "Fish & Chips" {
+		t.Errorf("Raw NAME = %q, want %q", txn.Raw["NAME"], "Fish & Chips")
+	}
+	if txn.Account != "0909090909" || !txn.Date.Equal(date(2024, 3, 2)) || txn.Amount != -1250 {
+		t.Errorf("transaction = %s %s %s, want 0909090909 2024-03-02 -12.50", txn.Account, txn.Date.Format("2006-01-02"), txn.Amount)
+	}
+}
+
+func TestParseQIFMissingTerminator(t *testing.T) {
+	txns, err := ingaugo.ParseQIF(strings.NewReader("!Type:Bank\nD01/03/2024\nT-4.50\nPCoffee\n^\nD02/03/2024\nT4.50\nMRefund\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) != 2 {
+		t.Fatalf("parsed %d transactions, want 2", len(txns))
+	}
+	if txns[1].Description != "Refund" || txns[1].Amount != 450 {
+		t.Errorf("last transaction = %q %s, want %q %s", txns[1].Description, txns[1].Amount, "Refund", ingaugo.Amount(450))
+	}
+}
@@ -0,0 +1,164 @@
+package ingaugo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	dp "github.com/chromedp/chromedp"
+)
+
+// browserPool shares one browser between logins. Each login gets its own incognito browser context,
+// so that cookies and storage are isolated between clients
+type browserPool struct {
+	bank *Bank
+	sem  chan struct{}
+
+	mu         sync.Mutex
+	browserCtx context.Context
+	cancel     context.CancelFunc
+}
+
+func newBrowserPool(bank *Bank, size int) *browserPool {
+	return &browserPool{
+		bank: bank,
+		sem:  make(chan struct{}, size),
+	}
+}
+
+// acquire waits for a free slot in the pool, starting the browser if it isn't running yet.
+// release must be called once the login is finished with the browser
+func (p *browserPool) acquire(ctx context.Context) (release func(), err error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case p.sem <- struct{}{}:
+	}
+	release = func() { <-p.sem }
+
+	if err := p.start(ctx); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// start launches or connects to the browser unless it is already running
+func (p *browserPool) start(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.browserCtx != nil && p.browserCtx.Err() == nil {
+		return nil
+	}
+
+	// the browser ends with the context it was started with, so it must outlive ctx
+	allocCtx, allocCancel := p.bank.allocatorContext(context.Background())
+	browserCtx, cancel := dp.NewContext(allocCtx)
+	cancelBrowser := func() {
+		cancel()
+		allocCancel()
+	}
+	stop := cancelOnDone(ctx, cancelBrowser)
+	err := dp.Run(browserCtx)
+	stop()
+	if err != nil {
+		cancelBrowser()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("Chrome actions failed: %w", err)
+	}
+	p.bank.logger.Debug("Browser pool started")
+
+	p.browserCtx = browserCtx
+	p.cancel = cancelBrowser
+	return nil
+}
+
+// tab returns a context for a new tab in a new incognito browser context. The tab is closed when parent is done
+func (p *browserPool) tab(parent context.Context) (context.Context, context.CancelFunc) {
+	p.mu.Lock()
+	browserCtx := p.browserCtx
+	p.mu.Unlock()
+	if browserCtx == nil {
+		// the pool was closed after the browser was acquired, so there is no browser to open the tab in
+		ctx, cancel := context.WithCancel(parent)
+		cancel()
+		return ctx, cancel
+	}
+
+	ctx, cancel := dp.NewContext(browserCtx, dp.WithNewBrowserContext())
+	stop := cancelOnDone(parent, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// close shuts down the browser
+func (p *browserPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancel != nil {
+		p.cancel()
+		p.browserCtx = nil
+		p.cancel = nil
+	}
+}
+
+// Close shuts down the browser shared by logins when the Bank was created with WithBrowserPool.
+// Sessions can still log in again afterwards, which starts a new browser
+func (bank *Bank) Close() error {
+	if bank.pool != nil {
+		bank.pool.close()
+	}
+	return nil
+}
+
+// Credentials are the login details of an ING client
+type Credentials struct {
+	ClientNumber string
+	AccessPin    string
+}
+
+// LoginResult is the outcome of logging in one client with LoginAll. Err is set if the login failed
+type LoginResult struct {
+	ClientNumber string
+	Session      *Session
+	Err          error
+}
+
+// LoginAll logs in all clients and returns their results in the order of creds. With WithBrowserPool, up to
+// the pool size logins run concurrently in the shared browser, otherwise they run one at a time
+func (bank *Bank) LoginAll(ctx context.Context, creds []Credentials) []LoginResult {
+	limit := 1
+	if bank.pool != nil {
+		limit = cap(bank.pool.sem)
+	}
+	sem := make(chan struct{}, limit)
+
+	results := make([]LoginResult, len(creds))
+	var wg sync.WaitGroup
+	for i, c := range creds {
+		wg.Add(1)
+		go func(i int, c Credentials) {
+			defer wg.Done()
+			results[i].ClientNumber = c.ClientNumber
+			select {
+			case <-ctx.Done():
+				results[i].Err = ctx.Err()
+				return
+			case sem <- struct{}{}:
+			}
+			defer func() { <-sem }()
+
+			results[i].Session, results[i].Err = bank.Login(ctx, c.ClientNumber, c.AccessPin)
+			if results[i].Err != nil {
+				bank.logger.Warn("Login failed", "clientNumber", c.ClientNumber, "error", results[i].Err)
+			}
+		}(i, c)
+	}
+	wg.Wait()
+	return results
+}
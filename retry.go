@@ -0,0 +1,70 @@
+package ingaugo
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryPolicy controls how API calls that fail with a network error, 429 Too Many Requests or a server error are retried.
+// The wait before each retry doubles from InitialBackoff up to MaxBackoff, with random jitter of up to half the wait.
+// A longer Retry-After response header is honoured
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first. 1 disables retries
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait before a retry. 0 means no cap
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy used unless one is set with WithRetryPolicy
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Second,
+	MaxBackoff:     30 * time.Second,
+}
+
+var (
+	jitterMu   sync.Mutex
+	jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// backoff returns how long to wait after the given failed attempt, starting from 1
+func (p RetryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	backoff := p.InitialBackoff
+	// without a MaxBackoff, stop doubling before the duration overflows
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || backoff < p.MaxBackoff) && backoff <= math.MaxInt64/2; i++ {
+		backoff *= 2
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	if half := int64(backoff / 2); half > 0 {
+		jitterMu.Lock()
+		backoff = time.Duration(half + jitterRand.Int63n(half+1))
+		jitterMu.Unlock()
+	}
+	if retryAfter > backoff {
+		backoff = retryAfter
+	}
+	return backoff
+}
+
+// parseRetryAfter returns the wait requested by a Retry-After header, given either in seconds or as an HTTP date
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
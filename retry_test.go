@@ -0,0 +1,190 @@
+package ingaugo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+// newRetryTestBank returns a Bank using policy that posts to a server replying with statuses in turn, and the
+// number of requests the server has seen. Each status is given as the code and an optional Retry-After header
+func newRetryTestBank(t *testing.T, policy RetryPolicy, statuses ...[2]string) (*Bank, string, *int32) {
+	t.Helper()
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&requests, 1))
+		if n > len(statuses) {
+			n = len(statuses)
+		}
+		status := statuses[n-1]
+		if status[1] != "" {
+			w.Header().Set("Retry-After", status[1])
+		}
+		code, _ := strconv.Atoi(status[0])
+		w.WriteHeader(code)
+		if code == http.StatusOK {
+			io.WriteString(w, "ok")
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	bank, err := NewBank(
+		WithBaseURL(srv.URL),
+		WithRetryPolicy(policy),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bank, srv.URL + "/api", &requests
+}
+
+var fastRetryPolicy = RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+func TestPostRetries(t *testing.T) {
+	bank, u, requests := newRetryTestBank(t, fastRetryPolicy, [2]string{"503"}, [2]string{"429", "1"}, [2]string{"200"})
+
+	start := time.Now()
+	body, err := bank.post(context.Background(), u, url.Values{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "ok" {
+		t.Errorf("body = %q, want %q", body, "ok")
+	}
+	if n := atomic.LoadInt32(requests); n != 3 {
+		t.Errorf("server saw %d requests, want 3", n)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want at least the Retry-After of 1s", elapsed)
+	}
+}
+
+func TestPostRetriesExhausted(t *testing.T) {
+	policy := fastRetryPolicy
+	policy.MaxAttempts = 3
+	bank, u, requests := newRetryTestBank(t, policy, [2]string{"503"})
+
+	_, err := bank.post(context.Background(), u, url.Values{})
+	if !errors.Is(err, ErrBankUnavailable) {
+		t.Fatalf("error = %v, want ErrBankUnavailable", err)
+	}
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("error = %v, want *HTTPError with status 503", err)
+	}
+	if n := atomic.LoadInt32(requests); n != 3 {
+		t.Errorf("server saw %d requests, want 3", n)
+	}
+}
+
+func TestPostNoRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		status  string
+		wantErr error
+	}{
+		{name: "not found", policy: fastRetryPolicy, status: "404"},
+		{name: "unauthorized", policy: fastRetryPolicy, status: "401", wantErr: ErrTokenExpired},
+		{name: "retries disabled", policy: RetryPolicy{MaxAttempts: 1}, status: "503", wantErr: ErrBankUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bank, u, requests := newRetryTestBank(t, tt.policy, [2]string{tt.status}, [2]string{"200"})
+
+			_, err := bank.post(context.Background(), u, url.Values{})
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) {
+				t.Fatalf("error = %v, want *HTTPError", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			if n := atomic.LoadInt32(requests); n != 1 {
+				t.Errorf("server saw %d requests, want 1", n)
+			}
+		})
+	}
+}
+
+func TestPostRetryCancelled(t *testing.T) {
+	bank, u, requests := newRetryTestBank(t, fastRetryPolicy, [2]string{"503", "60"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := bank.post(ctx, u, url.Values{})
+	if !errors.Is(err, ErrBankUnavailable) {
+		t.Errorf("error = %v, want ErrBankUnavailable", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("cancelled retry returned after %s", elapsed)
+	}
+	if n := atomic.LoadInt32(requests); n != 1 {
+		t.Errorf("server saw %d requests, want 1", n)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Second, MaxBackoff: 8 * time.Second}
+	uncapped := RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Second}
+	tests := []struct {
+		policy     RetryPolicy
+		attempt    int
+		retryAfter time.Duration
+		min, max   time.Duration
+	}{
+		{policy: policy, attempt: 1, min: 500 * time.Millisecond, max: time.Second},
+		{policy: policy, attempt: 2, min: time.Second, max: 2 * time.Second},
+		{policy: policy, attempt: 3, min: 2 * time.Second, max: 4 * time.Second},
+		{policy: policy, attempt: 4, min: 4 * time.Second, max: 8 * time.Second},
+		{policy: policy, attempt: 9, min: 4 * time.Second, max: 8 * time.Second},
+		// a longer Retry-After wins, a shorter one doesn't cut the backoff
+		{policy: policy, attempt: 1, retryAfter: 20 * time.Second, min: 20 * time.Second, max: 20 * time.Second},
+		{policy: policy, attempt: 4, retryAfter: time.Second, min: 4 * time.Second, max: 8 * time.Second},
+		// without a MaxBackoff the wait keeps growing
+		{policy: uncapped, attempt: 1, min: 500 * time.Millisecond, max: time.Second},
+		{policy: uncapped, attempt: 2, min: time.Second, max: 2 * time.Second},
+		{policy: uncapped, attempt: 4, min: 4 * time.Second, max: 8 * time.Second},
+		{policy: uncapped, attempt: 7, min: 32 * time.Second, max: 64 * time.Second},
+		{policy: uncapped, attempt: 1000, min: time.Duration(math.MaxInt64 / 4), max: time.Duration(math.MaxInt64)},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 50; i++ {
+			if got := tt.policy.backoff(tt.attempt, tt.retryAfter); got < tt.min || got > tt.max {
+				t.Fatalf("%+v backoff(%d, %s) = %s, want between %s and %s", tt.policy, tt.attempt, tt.retryAfter, got, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		header   string
+		min, max time.Duration
+	}{
+		{header: ""},
+		{header: "0"},
+		{header: "-5"},
+		{header: "soon"},
+		{header: "120", min: 120 * time.Second, max: 120 * time.Second},
+		{header: time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), min: 50 * time.Second, max: time.Minute},
+		{header: time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.header); got < tt.min || got > tt.max {
+			t.Errorf("parseRetryAfter(%q) = %s, want between %s and %s", tt.header, got, tt.min, tt.max)
+		}
+	}
+}
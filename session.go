@@ -0,0 +1,149 @@
+package ingaugo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Session holds the authentication token for a client. It logs in again when the token
+// has expired or is rejected by the API. A Session is safe for concurrent use
+type Session struct {
+	bank         *Bank
+	clientNumber string
+	accessPin    string
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+// Bank returns the Bank the session was created from
+func (s *Session) Bank() *Bank {
+	return s.bank
+}
+
+// ClientNumber returns the ING client number the session belongs to
+func (s *Session) ClientNumber() string {
+	return s.clientNumber
+}
+
+// Token returns the current authentication token
+func (s *Session) Token() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token
+}
+
+// IssuedAt returns the time the current token was issued
+func (s *Session) IssuedAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.issuedAt
+}
+
+// Expired reports whether the token is older than the Bank's token lifetime
+func (s *Session) Expired() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.expired()
+}
+
+func (s *Session) expired() bool {
+	return time.Since(s.issuedAt) > s.bank.tokenLifetime
+}
+
+// Refresh logs in again and replaces the token
+func (s *Session) Refresh(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.refresh(ctx)
+}
+
+func (s *Session) refresh(ctx context.Context) error {
+	s.bank.logger.Info("Refreshing session", "clientNumber", s.clientNumber)
+	token, err := s.bank.loginWithRetry(ctx, s.clientNumber, s.accessPin)
+	if err != nil {
+		return err
+	}
+	s.token = token
+	s.issuedAt = time.Now()
+	if s.bank.tokenStore != nil {
+		stored := StoredToken{Token: s.token, IssuedAt: s.issuedAt}
+		if err := s.bank.tokenStore.Save(ctx, s.clientNumber, stored); err != nil {
+			s.bank.logger.Warn("Saving token failed", "error", err)
+		}
+	}
+	return nil
+}
+
+// validToken returns the current token, logging in again first if it has expired
+func (s *Session) validToken(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.expired() {
+		if err := s.refresh(ctx); err != nil {
+			return "", err
+		}
+	}
+	return s.token, nil
+}
+
+// invalidate logs in again if token is still the current token. Concurrent callers
+// that saw the same rejected token only trigger one login
+func (s *Session) invalidate(ctx context.Context, token string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == token {
+		if err := s.refresh(ctx); err != nil {
+			return "", err
+		}
+	}
+	return s.token, nil
+}
+
+// do calls fn with a valid token, retrying once with a new token if the API rejects it
+func (s *Session) do(ctx context.Context, fn func(token string) error) error {
+	token, err := s.validToken(ctx)
+	if err != nil {
+		return err
+	}
+	err = fn(token)
+	if !errors.Is(err, ErrTokenExpired) {
+		return err
+	}
+	s.bank.logger.Info("Token rejected", "clientNumber", s.clientNumber)
+	token, err = s.invalidate(ctx, token)
+	if err != nil {
+		return err
+	}
+	return fn(token)
+}
+
+// TransactionsDays fetches transactions for the last x days. See Bank.GetTransactionsDays
+func (s *Session) TransactionsDays(ctx context.Context, days int, format Format, accountNumber string) (body []byte, err error) {
+	err = s.do(ctx, func(token string) error {
+		body, err = s.bank.GetTransactionsDays(ctx, days, format, accountNumber, token)
+		return err
+	})
+	return
+}
+
+// TransactionsRange fetches transactions between from and to. See Bank.GetTransactionsRange
+func (s *Session) TransactionsRange(ctx context.Context, from, to time.Time, format Format, accountNumber string) (body []byte, err error) {
+	err = s.do(ctx, func(token string) error {
+		body, err = s.bank.GetTransactionsRange(ctx, from, to, format, accountNumber, token)
+		return err
+	})
+	return
+}
+
+// Transactions fetches and parses transactions for the last x days. See Bank.GetTransactions
+func (s *Session) Transactions(ctx context.Context, days int, format Format, accountNumber string) (txns []Transaction, err error) {
+	err = s.do(ctx, func(token string) error {
+		txns, err = s.bank.GetTransactions(ctx, days, format, accountNumber, token)
+		return err
+	})
+	return
+}
@@ -0,0 +1,267 @@
+// Package sqlitestore keeps transactions, accounts and balance snapshots downloaded with ingaugo in a SQLite database,
+// so that the history can be queried with SQL. Writes are upserts, so the same data can be stored again safely.
+// Store implements ingaugo.TransactionStore for use with Session.Sync.
+//
+// The schema is created and migrated by Open. Dates are TEXT in YYYY-MM-DD format, times are TEXT in RFC 3339
+// format in UTC and amounts are INTEGER cents:
+//
+//	CREATE TABLE accounts (
+//		number       TEXT PRIMARY KEY,
+//		bsb          TEXT NOT NULL,
+//		product_name TEXT NOT NULL,
+//		nickname     TEXT NOT NULL,
+//		type         TEXT NOT NULL,
+//		updated_at   TEXT NOT NULL
+//	);
+//
+//	CREATE TABLE transactions (
+//		account     TEXT NOT NULL,
+//		id          TEXT NOT NULL,  -- ingaugo.Transaction.ID
+//		date        TEXT NOT NULL,
+//		amount      INTEGER NOT NULL,
+//		description TEXT NOT NULL,
+//		balance     INTEGER,        -- running balance, NULL when the export format has none
+//		raw         TEXT NOT NULL,  -- JSON object of the exported fields
+//		first_seen  TEXT NOT NULL,
+//		PRIMARY KEY (account, id)
+//	);
+//	CREATE INDEX transactions_account_date ON transactions (account, date);
+//
+//	CREATE TABLE balances (
+//		account   TEXT NOT NULL,
+//		time      TEXT NOT NULL,
+//		current   INTEGER NOT NULL,
+//		available INTEGER NOT NULL,
+//		PRIMARY KEY (account, time)
+//	);
+//
+// The schema version is kept in PRAGMA user_version.
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/porjo/ingaugo"
+
+	// pure Go driver, so that the CLI still builds with CGO_ENABLED=0
+	_ "modernc.org/sqlite"
+)
+
+const dateLayout = "2006-01-02"
+
+// migrations are applied in order. Append new migrations; never change one that has been released
+var migrations = []string{
+	`CREATE TABLE accounts (
+		number       TEXT PRIMARY KEY,
+		bsb          TEXT NOT NULL,
+		product_name TEXT NOT NULL,
+		nickname     TEXT NOT NULL,
+		type         TEXT NOT NULL,
+		updated_at   TEXT NOT NULL
+	);
+	CREATE TABLE transactions (
+		account     TEXT NOT NULL,
+		id          TEXT NOT NULL,
+		date        TEXT NOT NULL,
+		amount      INTEGER NOT NULL,
+		description TEXT NOT NULL,
+		balance     INTEGER,
+		raw         TEXT NOT NULL,
+		first_seen  TEXT NOT NULL,
+		PRIMARY KEY (account, id)
+	);
+	CREATE INDEX transactions_account_date ON transactions (account, date);
+	CREATE TABLE balances (
+		account   TEXT NOT NULL,
+		time      TEXT NOT NULL,
+		current   INTEGER NOT NULL,
+		available INTEGER NOT NULL,
+		PRIMARY KEY (account, time)
+	);`,
+}
+
+// Store is a SQLite database of ingaugo data. It is safe for concurrent use
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens or creates the database at path and migrates it to the latest schema
+func Open(ctx context.Context, path string) (*Store, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	store := &Store{db: db}
+	if err := store.migrate(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating %s: %w", path, err)
+	}
+	return store, nil
+}
+
+// Close closes the database
+func (store *Store) Close() error {
+	return store.db.Close()
+}
+
+// DB returns the underlying database e.g. for queries
+func (store *Store) DB() *sql.DB {
+	return store.db
+}
+
+func (store *Store) migrate(ctx context.Context) error {
+	var version int
+	if err := store.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("schema version %d is newer than this version of sqlitestore supports", version)
+	}
+	for ; version < len(migrations); version++ {
+		tx, err := store.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, migrations[version]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", version+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Transactions implements ingaugo.TransactionStore
+func (store *Store) Transactions(ctx context.Context, accountNumber string, since time.Time) ([]ingaugo.Transaction, error) {
+	rows, err := store.db.QueryContext(ctx, `SELECT id, date, amount, description, balance, raw FROM transactions
+		WHERE account = ? AND date >= ? ORDER BY date, rowid`, accountNumber, since.Format(dateLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	txns := make([]ingaugo.Transaction, 0)
+	for rows.Next() {
+		t := ingaugo.Transaction{Account: accountNumber}
+		var date, raw string
+		var balance sql.NullInt64
+		if err := rows.Scan(&t.ID, &date, &t.Amount, &t.Description, &balance, &raw); err != nil {
+			return nil, err
+		}
+		if t.Date, err = time.Parse(dateLayout, date); err != nil {
+			return nil, err
+		}
+		if balance.Valid {
+			b := ingaugo.Amount(balance.Int64)
+			t.Balance = &b
+		}
+		if err := json.Unmarshal([]byte(raw), &t.Raw); err != nil {
+			return nil, err
+		}
+		txns = append(txns, t)
+	}
+	return txns, rows.Err()
+}
+
+// Append implements ingaugo.TransactionStore by upserting txns into the account
+func (store *Store) Append(ctx context.Context, accountNumber string, txns []ingaugo.Transaction) error {
+	withAccount := make([]ingaugo.Transaction, len(txns))
+	for i, t := range txns {
+		t.Account = accountNumber
+		withAccount[i] = t
+	}
+	return store.UpsertTransactions(ctx, withAccount)
+}
+
+// UpsertTransactions inserts transactions, or updates them if a transaction with the same account and ID is
+// already stored. Each transaction must have its Account and ID set
+func (store *Store) UpsertTransactions(ctx context.Context, txns []ingaugo.Transaction) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	return store.inTx(ctx, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, `INSERT INTO transactions (account, id, date, amount, description, balance, raw, first_seen)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (account, id) DO UPDATE SET
+				date = excluded.date, amount = excluded.amount, description = excluded.description,
+				balance = excluded.balance, raw = excluded.raw`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, t := range txns {
+			if t.Account == "" || t.ID == "" {
+				return fmt.Errorf("transaction %s %q has no account or ID", t.Date.Format(dateLayout), t.Description)
+			}
+			var balance sql.NullInt64
+			if t.Balance != nil {
+				balance = sql.NullInt64{Int64: int64(*t.Balance), Valid: true}
+			}
+			raw := t.Raw
+			if raw == nil {
+				raw = map[string]string{}
+			}
+			rawJSON, err := json.Marshal(raw)
+			if err != nil {
+				return err
+			}
+			if _, err := stmt.ExecContext(ctx, t.Account, t.ID, t.Date.Format(dateLayout), int64(t.Amount),
+				t.Description, balance, string(rawJSON), now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpsertAccounts inserts accounts, or updates them if already stored. Balances are stored separately with AddBalances
+func (store *Store) UpsertAccounts(ctx context.Context, accounts []ingaugo.Account) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	return store.inTx(ctx, func(tx *sql.Tx) error {
+		for _, a := range accounts {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO accounts (number, bsb, product_name, nickname, type, updated_at)
+				VALUES (?, ?, ?, ?, ?, ?)
+				ON CONFLICT (number) DO UPDATE SET
+					bsb = excluded.bsb, product_name = excluded.product_name, nickname = excluded.nickname,
+					type = excluded.type, updated_at = excluded.updated_at`,
+				a.Number, a.BSB, a.ProductName, a.Nickname, a.Type, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AddBalances stores balance snapshots. A snapshot of the same account at the same time replaces the stored one
+func (store *Store) AddBalances(ctx context.Context, balances []ingaugo.Balance) error {
+	return store.inTx(ctx, func(tx *sql.Tx) error {
+		for _, b := range balances {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO balances (account, time, current, available) VALUES (?, ?, ?, ?)
+				ON CONFLICT (account, time) DO UPDATE SET current = excluded.current, available = excluded.available`,
+				b.AccountNumber, b.Time.UTC().Format(time.RFC3339), int64(b.Current), int64(b.Available)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (store *Store) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,162 @@
+package sqlitestore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/porjo/ingaugo"
+)
+
+func amountPtr(a ingaugo.Amount) *ingaugo.Amount {
+	return &a
+}
+
+func count(t *testing.T, store *Store, table string) int {
+	t.Helper()
+	var n int
+	if err := store.DB().QueryRow("SELECT count(*) FROM " + table).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestOpenMigrations(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "ingaugo.db")
+
+	for i := 0; i < 2; i++ {
+		store, err := Open(ctx, path)
+		if err != nil {
+			t.Fatalf("Open %d: %v", i+1, err)
+		}
+		var version int
+		if err := store.DB().QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+			t.Fatal(err)
+		}
+		if version != len(migrations) {
+			t.Errorf("Open %d: user_version = %d, want %d", i+1, version, len(migrations))
+		}
+		for _, table := range []string{"accounts", "transactions", "balances"} {
+			count(t, store, table)
+		}
+		if err := store.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a database from a newer version is refused rather than used with the wrong schema
+	store, err := Open(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.DB().Exec("PRAGMA user_version = 99"); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+	if store, err := Open(ctx, path); err == nil {
+		store.Close()
+		t.Error("Open of a newer schema succeeded")
+	}
+}
+
+func TestUpsertTransactions(t *testing.T) {
+	ctx := context.Background()
+	store, err := Open(ctx, filepath.Join(t.TempDir(), "ingaugo.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	day := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	txns := []ingaugo.Transaction{
+		{Account: "0909090909", Date: day.AddDate(0, 0, -1), Amount: 10000, Description: "Pay", Balance: amountPtr(10000), Raw: map[string]string{"Description": "Pay"}},
+		{Account: "0909090909", Date: day, Amount: -450, Description: "Coffee", Balance: amountPtr(9550)},
+		{Account: "0909090909", Date: day, Amount: -450, Description: "Coffee"},
+	}
+	ingaugo.AssignIDs(txns)
+
+	for i := 0; i < 2; i++ {
+		if err := store.UpsertTransactions(ctx, txns); err != nil {
+			t.Fatalf("UpsertTransactions %d: %v", i+1, err)
+		}
+	}
+	if n := count(t, store, "transactions"); n != len(txns) {
+		t.Fatalf("stored %d transactions after upserting twice, want %d", n, len(txns))
+	}
+
+	// an upsert updates the stored transaction
+	updated := txns[0]
+	updated.Description = "Salary"
+	if err := store.UpsertTransactions(ctx, []ingaugo.Transaction{updated}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.Transactions(ctx, "0909090909", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(txns) {
+		t.Fatalf("Transactions returned %d, want %d", len(got), len(txns))
+	}
+	if got[0].Description != "Salary" || got[0].ID != txns[0].ID || got[0].Raw["Description"] != "Pay" {
+		t.Errorf("updated transaction = %+v", got[0])
+	}
+	for i := range txns {
+		if got[i].ID != txns[i].ID || !got[i].Date.Equal(txns[i].Date) || got[i].Amount != txns[i].Amount {
+			t.Errorf("transaction %d = %+v, want %+v", i, got[i], txns[i])
+		}
+	}
+	if got[1].Balance == nil || *got[1].Balance != 9550 || got[2].Balance != nil {
+		t.Errorf("balances = %v, %v, want 95.50 and nil", got[1].Balance, got[2].Balance)
+	}
+
+	since, err := store.Transactions(ctx, "0909090909", day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(since) != 2 {
+		t.Errorf("Transactions since %s returned %d, want 2", day.Format(dateLayout), len(since))
+	}
+
+	if err := store.UpsertTransactions(ctx, []ingaugo.Transaction{{Date: day, Description: "no ID"}}); err == nil {
+		t.Error("UpsertTransactions without account and ID succeeded")
+	}
+}
+
+func TestAccountsAndBalances(t *testing.T) {
+	ctx := context.Background()
+	store, err := Open(ctx, filepath.Join(t.TempDir(), "ingaugo.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	accounts := []ingaugo.Account{{Number: "0909090909", BSB: "923100", ProductName: "Orange Everyday"}}
+	for i := 0; i < 2; i++ {
+		if err := store.UpsertAccounts(ctx, accounts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := count(t, store, "accounts"); n != 1 {
+		t.Errorf("stored %d accounts, want 1", n)
+	}
+
+	now := time.Now()
+	balances := []ingaugo.Balance{{AccountNumber: "0909090909", Current: 9550, Available: 9000, Time: now}}
+	if err := store.AddBalances(ctx, balances); err != nil {
+		t.Fatal(err)
+	}
+	// the same snapshot again replaces it, a later one is added
+	if err := store.AddBalances(ctx, balances); err != nil {
+		t.Fatal(err)
+	}
+	balances[0].Time = now.Add(time.Hour)
+	if err := store.AddBalances(ctx, balances); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(t, store, "balances"); n != 2 {
+		t.Errorf("stored %d balances, want 2", n)
+	}
+}
@@ -0,0 +1,81 @@
+package ingaugo
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// TransactionStore keeps the transactions downloaded by Session.Sync
+type TransactionStore interface {
+	// Transactions returns the stored transactions of the account dated on or after since
+	Transactions(ctx context.Context, accountNumber string, since time.Time) ([]Transaction, error)
+	// Append adds transactions to the account in the order given
+	Append(ctx context.Context, accountNumber string, txns []Transaction) error
+}
+
+// CSVStore is a TransactionStore keeping each account in a CSV file named <account number>.csv,
+// in the layout written by WriteCSV
+type CSVStore struct {
+	dir string
+}
+
+// NewCSVStore returns a CSVStore keeping its files in dir
+func NewCSVStore(dir string) *CSVStore {
+	return &CSVStore{dir: dir}
+}
+
+func (store *CSVStore) path(accountNumber string) string {
+	return filepath.Join(store.dir, accountNumber+".csv")
+}
+
+// Transactions implements TransactionStore
+func (store *CSVStore) Transactions(ctx context.Context, accountNumber string, since time.Time) ([]Transaction, error) {
+	f, err := os.Open(store.path(accountNumber))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	all, err := ParseCSV(f)
+	if err != nil {
+		return nil, err
+	}
+	txns := make([]Transaction, 0, len(all))
+	for _, t := range all {
+		if t.Date.Before(since) {
+			continue
+		}
+		t.Account = accountNumber
+		txns = append(txns, t)
+	}
+	return txns, nil
+}
+
+// Append implements TransactionStore. The header row is written when the file is created
+func (store *CSVStore) Append(ctx context.Context, accountNumber string, txns []Transaction) error {
+	f, err := os.OpenFile(store.path(accountNumber), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if info.Size() == 0 {
+		err = WriteCSV(f, txns)
+	} else {
+		err = writeCSVRows(csv.NewWriter(f), txns)
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,200 @@
+package ingaugo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSyncOverlapDays is how many days before the watermark Sync requests again by default
+	DefaultSyncOverlapDays = 7
+	// DefaultSyncInitialDays is how many days Sync requests by default for an account without a watermark
+	DefaultSyncInitialDays = 30
+)
+
+// Watermark records how far Sync has got for an account
+type Watermark struct {
+	// Date is the date of the latest transaction seen. It is zero until a transaction has been seen
+	Date time.Time
+	// ID is the ID of the latest transaction seen
+	ID string `json:",omitempty"`
+	// Synced is when the account was last synced
+	Synced time.Time
+}
+
+// SyncState holds the Watermark of each account. It is safe for concurrent use
+type SyncState struct {
+	mu         sync.Mutex
+	watermarks map[string]Watermark
+}
+
+// LoadSyncState reads a state file written by SyncState.Save. A missing file gives an empty state
+func LoadSyncState(path string) (*SyncState, error) {
+	state := &SyncState{watermarks: make(map[string]Watermark)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &state.watermarks); err != nil {
+		return nil, fmt.Errorf("sync state %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// Save writes the state to path as JSON, replacing the file atomically
+func (state *SyncState) Save(path string) error {
+	state.mu.Lock()
+	data, err := json.MarshalIndent(state.watermarks, "", "  ")
+	state.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// Watermark returns the watermark of accountNumber, if it has been synced before
+func (state *SyncState) Watermark(accountNumber string) (Watermark, bool) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	wm, ok := state.watermarks[accountNumber]
+	return wm, ok
+}
+
+// SetWatermark sets the watermark of accountNumber
+func (state *SyncState) SetWatermark(accountNumber string, wm Watermark) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.watermarks == nil {
+		state.watermarks = make(map[string]Watermark)
+	}
+	state.watermarks[accountNumber] = wm
+}
+
+// SyncOptions configures Session.Sync. Zero values select the defaults
+type SyncOptions struct {
+	// OverlapDays is how many days before the watermark are requested again, to pick up transactions posted late
+	OverlapDays int
+	// InitialDays is how many days are requested for an account without a watermark
+	InitialDays int
+}
+
+// Sync fetches the transactions of accountNumber since its watermark in state, less the overlap, and appends those
+// not already in store, going by their IDs. It returns the appended transactions and advances the watermark; save state afterwards.
+// Transactions are appended oldest first. If state isn't saved, the next Sync requests the same window again
+// and skips the transactions already stored
+func (s *Session) Sync(ctx context.Context, accountNumber string, state *SyncState, store TransactionStore, opts SyncOptions) ([]Transaction, error) {
+	if opts.OverlapDays <= 0 {
+		opts.OverlapDays = DefaultSyncOverlapDays
+	}
+	if opts.InitialDays <= 0 {
+		opts.InitialDays = DefaultSyncInitialDays
+	}
+
+	wm, _ := state.Watermark(accountNumber)
+	from, to := syncWindow(wm, time.Now(), opts)
+	s.bank.logger.Info("Syncing account", "accountNumber", accountNumber, "from", from.Format("2006-01-02"))
+
+	body, err := s.TransactionsRange(ctx, from, to, CSV, accountNumber)
+	if err != nil {
+		return nil, err
+	}
+	fetched, err := ParseCSV(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for i := range fetched {
+		fetched[i].Account = accountNumber
+	}
+	chronological(fetched)
+
+	// transaction dates are midnight UTC
+	since := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	existing, err := store.Transactions(ctx, accountNumber, since)
+	if err != nil {
+		return nil, err
+	}
+	added := newTransactions(existing, fetched)
+	if len(added) > 0 {
+		if err := store.Append(ctx, accountNumber, added); err != nil {
+			return nil, err
+		}
+	}
+
+	// fetched is oldest first, so the last transaction is the latest
+	if n := len(fetched); n > 0 && !fetched[n-1].Date.Before(wm.Date) {
+		wm.Date = fetched[n-1].Date
+		wm.ID = fetched[n-1].ID
+	}
+	wm.Synced = time.Now()
+	state.SetWatermark(accountNumber, wm)
+
+	return added, nil
+}
+
+// syncWindow returns the dates Sync requests for an account with watermark wm: from the watermark date less the overlap,
+// or the initial days before now when no transaction has been seen yet, e.g. for a dormant account, until the end of today
+func syncWindow(wm Watermark, now time.Time, opts SyncOptions) (from, to time.Time) {
+	now = now.In(sydney)
+	from = time.Date(now.Year(), now.Month(), now.Day()-opts.InitialDays, 0, 0, 0, 0, sydney)
+	if !wm.Date.IsZero() {
+		from = time.Date(wm.Date.Year(), wm.Date.Month(), wm.Date.Day()-opts.OverlapDays, 0, 0, 0, 0, sydney)
+	}
+	to = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, sydney)
+	return from, to
+}
+
+// chronological puts transactions in date order, oldest first. ING exports list the newest first,
+// so the order of transactions on the same date is reversed too
+func chronological(txns []Transaction) {
+	if newestFirst(txns) {
+		for i, j := 0, len(txns)-1; i < j; i, j = i+1, j-1 {
+			txns[i], txns[j] = txns[j], txns[i]
+		}
+	}
+	sort.SliceStable(txns, func(i, j int) bool {
+		return txns[i].Date.Before(txns[j].Date)
+	})
+}
+
+// newestFirst reports whether txns are listed newest first. When all transactions are on the same date,
+// the running balances of the first two tell the order. Without balances, e.g. from OFX and QIF exports,
+// they are assumed to be in ING's export order, newest first
+func newestFirst(txns []Transaction) bool {
+	if len(txns) < 2 {
+		return false
+	}
+	first, last := txns[0], txns[len(txns)-1]
+	if !first.Date.Equal(last.Date) {
+		return first.Date.After(last.Date)
+	}
+	second := txns[1]
+	if first.Balance == nil || second.Balance == nil {
+		return true
+	}
+	oldestFirst := *second.Balance == *first.Balance+second.Amount && *first.Balance != *second.Balance+first.Amount
+	return !oldestFirst
+}
+
+// newTransactions returns the transactions of fetched whose ID is not in existing
+func newTransactions(existing, fetched []Transaction) []Transaction {
+	seen := make(map[string]bool)
+	for _, t := range existing {
+		seen[t.ID] = true
+	}
+	added := make([]Transaction, 0)
+	for _, t := range fetched {
+		if !seen[t.ID] {
+			added = append(added, t)
+		}
+	}
+	return added
+}
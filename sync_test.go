@@ -0,0 +1,169 @@
+package ingaugo_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/porjo/ingaugo"
+	"github.com/porjo/ingaugo/ingtest"
+)
+
+func TestSyncWindow(t *testing.T) {
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	if err != nil {
+		t.Skip(err)
+	}
+	now := time.Date(2024, 3, 20, 15, 0, 0, 0, sydney)
+	opts := ingaugo.SyncOptions{OverlapDays: 7, InitialDays: 30}
+	tests := []struct {
+		name     string
+		wm       ingaugo.Watermark
+		wantFrom time.Time
+	}{
+		{
+			name:     "no watermark",
+			wantFrom: time.Date(2024, 2, 19, 0, 0, 0, 0, sydney),
+		},
+		{
+			// an account synced before without any transactions, e.g. a dormant account
+			name:     "no transaction seen",
+			wm:       ingaugo.Watermark{Synced: now.AddDate(0, 0, -1)},
+			wantFrom: time.Date(2024, 2, 19, 0, 0, 0, 0, sydney),
+		},
+		{
+			name:     "watermark",
+			wm:       ingaugo.Watermark{Date: date(2024, 3, 18), ID: "abc", Synced: now.AddDate(0, 0, -1)},
+			wantFrom: time.Date(2024, 3, 11, 0, 0, 0, 0, sydney),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to := ingaugo.SyncWindow(tt.wm, now, opts)
+			if !from.Equal(tt.wantFrom) {
+				t.Errorf("from = %s, want %s", from, tt.wantFrom)
+			}
+			if want := time.Date(2024, 3, 21, 0, 0, 0, 0, sydney); !to.Equal(want) {
+				t.Errorf("to = %s, want %s", to, want)
+			}
+		})
+	}
+}
+
+// issueToken logs in to the fake server's token endpoint directly, as the login page would, and stores the token
+// so that Login reuses it without a browser
+func issueToken(t *testing.T, srv *ingtest.Server, store ingaugo.TokenStore) {
+	t.Helper()
+	req, err := json.Marshal(map[string]string{"ClientNumber": testClientNumber, "AccessPin": testAccessPin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(srv.URL+"/api/token/login/issue", "application/json", bytes.NewReader(req))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var tr struct{ Token string }
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		t.Fatal(err)
+	}
+	if tr.Token == "" {
+		t.Fatal("no token issued")
+	}
+	if err := store.Save(context.Background(), testClientNumber, ingaugo.StoredToken{Token: tr.Token, IssuedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSync(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	srv := ingtest.NewServer(testClientNumber, testAccessPin)
+	defer srv.Close()
+
+	tokens, err := ingaugo.NewFileTokenStore(filepath.Join(dir, "tokens"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	issueToken(t, srv, tokens)
+	bank, err := ingaugo.NewBank(ingaugo.WithBaseURL(srv.URL), ingaugo.WithTokenStore(tokens), ingaugo.WithLogger(discardLogger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := bank.Login(ctx, testClientNumber, testAccessPin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const dormant = "0101010101"
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	coffee := ingaugo.Transaction{Date: today.AddDate(0, 0, -3), Amount: -450, Description: "Coffee"}
+	// ING exports list the newest first
+	srv.SetTransactions(testAccountNumber, []ingaugo.Transaction{coffee, coffee, {Date: today.AddDate(0, 0, -5), Amount: 10000, Description: "Pay"}})
+	srv.SetTransactions(dormant, []ingaugo.Transaction{})
+
+	state, err := ingaugo.LoadSyncState(filepath.Join(dir, "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := ingaugo.NewCSVStore(dir)
+
+	added, err := session.Sync(ctx, testAccountNumber, state, store, ingaugo.SyncOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 3 || added[0].Description != "Pay" || added[2].Description != "Coffee" {
+		t.Fatalf("first sync added %v, want Pay and two Coffees oldest first", added)
+	}
+	wm, ok := state.Watermark(testAccountNumber)
+	if !ok || !wm.Date.Equal(coffee.Date) || wm.ID != added[2].ID {
+		t.Errorf("watermark = %+v, want date %s and ID %s", wm, coffee.Date, added[2].ID)
+	}
+
+	if added, err := session.Sync(ctx, dormant, state, store, ingaugo.SyncOptions{}); err != nil || len(added) != 0 {
+		t.Fatalf("dormant sync added %d, error %v", len(added), err)
+	}
+	if wm, ok := state.Watermark(dormant); !ok || !wm.Date.IsZero() || wm.Synced.IsZero() {
+		t.Errorf("dormant watermark = %+v, want synced without a date", wm)
+	}
+	if err := state.Save(filepath.Join(dir, "state.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	// a transaction posted since, and one posted late within the overlap
+	refund := ingaugo.Transaction{Date: today.AddDate(0, 0, -1), Amount: 450, Description: "Refund"}
+	late := ingaugo.Transaction{Date: today.AddDate(0, 0, -4), Amount: -2000, Description: "Late"}
+	srv.SetTransactions(testAccountNumber, []ingaugo.Transaction{refund, coffee, coffee, late, {Date: today.AddDate(0, 0, -5), Amount: 10000, Description: "Pay"}})
+	srv.SetTransactions(dormant, []ingaugo.Transaction{{Date: today.AddDate(0, 0, -10), Amount: 100, Description: "Interest"}})
+
+	state, err = ingaugo.LoadSyncState(filepath.Join(dir, "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	added, err = session.Sync(ctx, testAccountNumber, state, store, ingaugo.SyncOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 2 || added[0].Description != "Late" || added[1].Description != "Refund" {
+		t.Errorf("second sync added %v, want Late and Refund", added)
+	}
+	added, err = session.Sync(ctx, dormant, state, store, ingaugo.SyncOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 1 {
+		t.Errorf("second dormant sync added %d transactions, want 1", len(added))
+	}
+
+	stored, err := store.Transactions(ctx, testAccountNumber, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 5 {
+		t.Errorf("store holds %d transactions, want 5", len(stored))
+	}
+}
@@ -0,0 +1,231 @@
+package ingaugo
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrTokenNotFound is returned by a TokenStore when it holds no token for a client
+var ErrTokenNotFound = errors.New("token not found")
+
+// StoredToken is an authentication token persisted by a TokenStore
+type StoredToken struct {
+	Token    string
+	IssuedAt time.Time
+}
+
+// TokenStore persists authentication tokens between runs so that Login can skip the browser
+// while a token is still valid
+type TokenStore interface {
+	// Load returns the token for clientNumber, or ErrTokenNotFound
+	Load(ctx context.Context, clientNumber string) (StoredToken, error)
+	Save(ctx context.Context, clientNumber string, token StoredToken) error
+	Delete(ctx context.Context, clientNumber string) error
+}
+
+// FileTokenStore is a TokenStore that keeps tokens in a single JSON file, readable only by the owner.
+// The file is optionally encrypted with AES-GCM
+type FileTokenStore struct {
+	path       string
+	passphrase string
+
+	mu sync.Mutex
+	// salt is the salt of the file last read or written, and aead uses the key derived from it
+	salt []byte
+	aead cipher.AEAD
+}
+
+const (
+	// encryptedTokenStoreMagic starts an encrypted token store file. It is followed by the scrypt salt,
+	// the AES-GCM nonce and the sealed JSON
+	encryptedTokenStoreMagic = "INGAUGO\x01"
+	tokenStoreSaltSize       = 16
+
+	// scrypt parameters for deriving the AES-256 key from the passphrase
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+// NewFileTokenStore returns a TokenStore backed by the file at path. If passphrase is not empty,
+// the file is encrypted with a key derived from it with scrypt and a random salt
+func NewFileTokenStore(path, passphrase string) (*FileTokenStore, error) {
+	return &FileTokenStore{path: path, passphrase: passphrase}, nil
+}
+
+// deriveKey sets aead to use the key derived from the passphrase and salt. The key is only derived again when the salt changes
+func (store *FileTokenStore) deriveKey(salt []byte) error {
+	if store.aead != nil && bytes.Equal(salt, store.salt) {
+		return nil
+	}
+	key, err := scrypt.Key([]byte(store.passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	store.salt = append([]byte(nil), salt...)
+	store.aead = aead
+	return nil
+}
+
+func (store *FileTokenStore) Load(ctx context.Context, clientNumber string) (StoredToken, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	tokens, err := store.read()
+	if err != nil {
+		return StoredToken{}, err
+	}
+	token, ok := tokens[clientNumber]
+	if !ok {
+		return StoredToken{}, ErrTokenNotFound
+	}
+	return token, nil
+}
+
+func (store *FileTokenStore) Save(ctx context.Context, clientNumber string, token StoredToken) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	tokens, err := store.read()
+	if err != nil {
+		return err
+	}
+	tokens[clientNumber] = token
+	return store.write(tokens)
+}
+
+func (store *FileTokenStore) Delete(ctx context.Context, clientNumber string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	tokens, err := store.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := tokens[clientNumber]; !ok {
+		return nil
+	}
+	delete(tokens, clientNumber)
+	return store.write(tokens)
+}
+
+func (store *FileTokenStore) read() (map[string]StoredToken, error) {
+	tokens := make(map[string]StoredToken)
+	data, err := os.ReadFile(store.path)
+	if os.IsNotExist(err) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if store.passphrase != "" {
+		if data, err = store.decrypt(data); err != nil {
+			return nil, fmt.Errorf("token store %s: %w", store.path, err)
+		}
+	}
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("token store %s: %w", store.path, err)
+	}
+	return tokens, nil
+}
+
+// write replaces the file atomically so that a crash never leaves a truncated store
+func (store *FileTokenStore) write(tokens map[string]StoredToken) error {
+	data, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	if store.passphrase != "" {
+		if data, err = store.encrypt(data); err != nil {
+			return err
+		}
+	}
+
+	return writeFileAtomic(store.path, data)
+}
+
+// decrypt opens an encrypted token store file
+func (store *FileTokenStore) decrypt(data []byte) ([]byte, error) {
+	headerSize := len(encryptedTokenStoreMagic) + tokenStoreSaltSize
+	if !bytes.HasPrefix(data, []byte(encryptedTokenStoreMagic)) || len(data) < headerSize {
+		return nil, fmt.Errorf("file is not encrypted, or was written by an older version and must be deleted")
+	}
+	header := data[:headerSize]
+	if err := store.deriveKey(header[len(encryptedTokenStoreMagic):]); err != nil {
+		return nil, err
+	}
+	data = data[headerSize:]
+	size := store.aead.NonceSize()
+	if len(data) < size {
+		return nil, fmt.Errorf("file is too short")
+	}
+	plaintext, err := store.aead.Open(nil, data[:size], data[size:], header)
+	if err != nil {
+		return nil, fmt.Errorf("decryption failed, wrong passphrase?: %w", err)
+	}
+	return plaintext, nil
+}
+
+// encrypt seals data with the salt of the file last read, or a new random salt for a new file.
+// The header is authenticated along with the data
+func (store *FileTokenStore) encrypt(data []byte) ([]byte, error) {
+	salt := store.salt
+	if salt == nil {
+		salt = make([]byte, tokenStoreSaltSize)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return nil, err
+		}
+	}
+	if err := store.deriveKey(salt); err != nil {
+		return nil, err
+	}
+	header := append([]byte(encryptedTokenStoreMagic), salt...)
+	nonce := make([]byte, store.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	out := append(append([]byte(nil), header...), nonce...)
+	return store.aead.Seal(out, nonce, data, header), nil
+}
+
+// writeFileAtomic writes data to a temporary file with 0600 permissions and renames it over path,
+// so that a crash never leaves a truncated file
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	// CreateTemp already uses 0600, but be explicit as the file may hold credentials
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,184 @@
+package ingaugo_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/porjo/ingaugo"
+)
+
+func TestFileTokenStore(t *testing.T) {
+	for _, passphrase := range []string{"", "correct horse battery staple"} {
+		name := "plain"
+		if passphrase != "" {
+			name = "encrypted"
+		}
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			path := filepath.Join(t.TempDir(), "tokens")
+			store, err := ingaugo.NewFileTokenStore(path, passphrase)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := store.Load(ctx, "12345678"); !errors.Is(err, ingaugo.ErrTokenNotFound) {
+				t.Fatalf("Load from missing file: error = %v, want ErrTokenNotFound", err)
+			}
+
+			want := ingaugo.StoredToken{Token: "secret-token", IssuedAt: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)}
+			if err := store.Save(ctx, "12345678", want); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Save(ctx, "87654321", ingaugo.StoredToken{Token: "other-token", IssuedAt: want.IssuedAt}); err != nil {
+				t.Fatal(err)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mode := info.Mode().Perm(); mode != 0600 {
+				t.Errorf("file mode = %o, want 600", mode)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if encrypted := !bytes.Contains(data, []byte(want.Token)); encrypted != (passphrase != "") {
+				t.Errorf("token readable in file = %t with passphrase %q", !encrypted, passphrase)
+			}
+
+			// a new store reads what the first one wrote
+			reopened, err := ingaugo.NewFileTokenStore(path, passphrase)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := reopened.Load(ctx, "12345678")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Token != want.Token || !got.IssuedAt.Equal(want.IssuedAt) {
+				t.Errorf("Load = %+v, want %+v", got, want)
+			}
+
+			if err := reopened.Delete(ctx, "12345678"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Load(ctx, "12345678"); !errors.Is(err, ingaugo.ErrTokenNotFound) {
+				t.Errorf("Load after Delete: error = %v, want ErrTokenNotFound", err)
+			}
+			if _, err := store.Load(ctx, "87654321"); err != nil {
+				t.Errorf("Load of remaining token: %v", err)
+			}
+			if err := store.Delete(ctx, "12345678"); err != nil {
+				t.Errorf("Delete of missing token: %v", err)
+			}
+
+			if passphrase == "" {
+				return
+			}
+			wrong, err := ingaugo.NewFileTokenStore(path, "wrong passphrase")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := wrong.Load(ctx, "87654321"); err == nil || errors.Is(err, ingaugo.ErrTokenNotFound) {
+				t.Errorf("Load with wrong passphrase: error = %v, want decryption error", err)
+			}
+			if err := wrong.Save(ctx, "87654321", want); err == nil {
+				t.Error("Save with wrong passphrase overwrote the store")
+			}
+			plain, err := ingaugo.NewFileTokenStore(path, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := plain.Load(ctx, "87654321"); err == nil {
+				t.Error("Load without passphrase succeeded")
+			}
+		})
+	}
+}
+
+func TestFileTokenStoreSalt(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	token := ingaugo.StoredToken{Token: "secret-token", IssuedAt: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)}
+
+	// the same passphrase must not give the same key for different files
+	var headers [][]byte
+	for _, name := range []string{"a", "b"} {
+		path := filepath.Join(dir, name)
+		store, err := ingaugo.NewFileTokenStore(path, "passphrase")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Save(ctx, "12345678", token); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		headers = append(headers, data[:24])
+	}
+	if bytes.Equal(headers[0], headers[1]) {
+		t.Errorf("files share a salt: %x", headers[0])
+	}
+}
+
+func TestStoredTokenValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantReused bool
+		wantKept   bool
+	}{
+		{name: "accepted", status: http.StatusOK, wantReused: true, wantKept: true},
+		{name: "rejected", status: http.StatusUnauthorized},
+		// the bank being unavailable says nothing about the token
+		{name: "unavailable", status: http.StatusServiceUnavailable, wantKept: true},
+		{name: "not found", status: http.StatusNotFound, wantKept: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			store, err := ingaugo.NewFileTokenStore(filepath.Join(t.TempDir(), "tokens"), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored := ingaugo.StoredToken{Token: "secret-token", IssuedAt: time.Now()}
+			if err := store.Save(ctx, testClientNumber, stored); err != nil {
+				t.Fatal(err)
+			}
+			bank, err := ingaugo.NewBank(
+				ingaugo.WithBaseURL(srv.URL),
+				ingaugo.WithTokenStore(store),
+				ingaugo.WithRetryPolicy(ingaugo.RetryPolicy{MaxAttempts: 1}),
+				ingaugo.WithLogger(discardLogger),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, reused := ingaugo.LoadStoredToken(bank, ctx, testClientNumber)
+			if reused != tt.wantReused || (reused && got.Token != stored.Token) {
+				t.Errorf("storedToken = %+v, %t, want reused %t", got, reused, tt.wantReused)
+			}
+			_, err = store.Load(ctx, testClientNumber)
+			if kept := err == nil; kept != tt.wantKept {
+				t.Errorf("token kept = %t (%v), want %t", kept, err, tt.wantKept)
+			}
+		})
+	}
+}
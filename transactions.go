@@ -1,28 +1,43 @@
 package ingaugo
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
-	"strings"
 	"time"
+
+	// ING expects Sydney local time; don't rely on the host having tzdata installed
+	_ "time/tzdata"
 )
 
 const (
-	timeLayout            = "2006-01-02T15:04:05-0700"
-	exportTransactionsURL = "https://www.ing.com.au/api/ExportTransactions/Service/ExportTransactionsService.svc/json/ExportTransactions/ExportTransactions"
-
-	// Make Go HTTP client user-agent match headless-shell user-agent
-	userAgent = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/98.0.4758.102 Safari/537.36"
+	timeLayout = "2006-01-02T15:04:05-0700"
 
 	CSV Format = "csv"
 	OFX Format = "ofx"
 	QIF Format = "qif"
+
+	// Beancount, Ledger, JSON and NDJSON are generated client-side from parsed transactions by WriteTransactions
+	Beancount Format = "beancount"
+	Ledger    Format = "ledger"
+	JSON      Format = "json"
+	NDJSON    Format = "ndjson"
 )
 
 type Format = string
 
+var sydney = mustLoadLocation("Australia/Sydney")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
 /*
 type transactionRequest struct {
 	AuthToken       string `qs:"X-AuthToken"`
@@ -34,38 +49,68 @@ type transactionRequest struct {
 }
 */
 
-// GetTransactionsDays fetches transactions for the last x days. It takes an account number and auth token
-func (bank *Bank) GetTransactionsDays(days int, format Format, accountNumber, authToken string) ([]byte, error) {
-	data := url.Values{}
-	data.Set("X-AuthToken", authToken)
-	data.Set("AccountNumber", accountNumber)
-	data.Set("Format", string(format))
-	data.Set("FilterStartDate", time.Now().AddDate(0, 0, -days).Format(timeLayout))
-	data.Set("FilterEndDate", time.Now().AddDate(0, 0, 1).Format(timeLayout))
-	data.Set("IsSpecific", "false")
-
-	c := &http.Client{}
+// GetTransactionsDays fetches transactions for the last x days. It takes a context, account number and auth token
+func (bank *Bank) GetTransactionsDays(ctx context.Context, days int, format Format, accountNumber, authToken string) ([]byte, error) {
+	return bank.exportTransactions(ctx, daysRequest(days, format, accountNumber, authToken))
+}
 
-	bank.logger.Info("Fetching page", "url", exportTransactionsURL)
-	req, err := http.NewRequest("POST", exportTransactionsURL, strings.NewReader(data.Encode()))
+// GetTransactions fetches transactions for the last x days and parses them. The Account field of each transaction is set to accountNumber
+func (bank *Bank) GetTransactions(ctx context.Context, days int, format Format, accountNumber, authToken string) ([]Transaction, error) {
+	body, err := bank.exportTransactions(ctx, daysRequest(days, format, accountNumber, authToken))
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("User-Agent", userAgent)
-	resp, err := c.Do(req)
+	txns, err := ParseTransactions(format, body)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	for i := range txns {
+		txns[i].Account = accountNumber
 	}
-	if resp.StatusCode != 200 {
-		bank.logger.Info("Response body", "body", string(body))
-		return nil, fmt.Errorf("error fetching transactions. Status code: %d", resp.StatusCode)
+	return txns, nil
+}
+
+// GetTransactionsRange fetches transactions between from and to. The times are sent as given, converted to
+// the Australia/Sydney timezone. To fetch whole days, pass midnight at the start and end of the range in that timezone
+func (bank *Bank) GetTransactionsRange(ctx context.Context, from, to time.Time, format Format, accountNumber, authToken string) ([]byte, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("invalid range: %s is before %s", to, from)
 	}
+	return bank.exportTransactions(ctx, rangeRequest(from, to, format, accountNumber, authToken))
+}
+
+func rangeRequest(from, to time.Time, format Format, accountNumber, authToken string) url.Values {
+	data := url.Values{}
+	data.Set("X-AuthToken", authToken)
+	data.Set("AccountNumber", accountNumber)
+	data.Set("Format", string(format))
+	data.Set("FilterStartDate", from.In(sydney).Format(timeLayout))
+	data.Set("FilterEndDate", to.In(sydney).Format(timeLayout))
+	data.Set("IsSpecific", "true")
+	return data
+}
 
-	return body, nil
+func daysRequest(days int, format Format, accountNumber, authToken string) url.Values {
+	data := url.Values{}
+	data.Set("X-AuthToken", authToken)
+	data.Set("AccountNumber", accountNumber)
+	data.Set("Format", string(format))
+	data.Set("FilterStartDate", time.Now().AddDate(0, 0, -days).Format(timeLayout))
+	data.Set("FilterEndDate", time.Now().AddDate(0, 0, 1).Format(timeLayout))
+	data.Set("IsSpecific", "false")
+	return data
+}
+
+func (bank *Bank) exportTransactions(ctx context.Context, data url.Values) ([]byte, error) {
+	switch format := data.Get("Format"); format {
+	case CSV, OFX, QIF:
+	default:
+		return nil, fmt.Errorf("format %q is not exported by ING, fetch CSV and use WriteTransactions", format)
+	}
+	body, err := bank.post(ctx, bank.exportTransactionsURL(), data)
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+		httpErr.err = ErrAccountNotFound
+	}
+	return body, err
 }
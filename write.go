@@ -0,0 +1,37 @@
+package ingaugo
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// csvHeader matches the columns of ING's CSV export, so that ParseCSV can read the output of WriteCSV
+var csvHeader = []string{"Date", "Description", "Credit", "Debit", "Balance"}
+
+// WriteCSV writes transactions in the layout of ING's CSV export, with a header row
+func WriteCSV(w io.Writer, txns []Transaction) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	return writeCSVRows(cw, txns)
+}
+
+func writeCSVRows(cw *csv.Writer, txns []Transaction) error {
+	for _, t := range txns {
+		var credit, debit, balance string
+		if t.Amount < 0 {
+			debit = t.Amount.String()
+		} else {
+			credit = t.Amount.String()
+		}
+		if t.Balance != nil {
+			balance = t.Balance.String()
+		}
+		if err := cw.Write([]string{t.Date.Format("02/01/2006"), t.Description, credit, debit, balance}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}